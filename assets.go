@@ -0,0 +1,29 @@
+package main
+
+import (
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"path"
+)
+
+// assetFS holds the fonts and icons referenced by the CV templates. They are
+// inlined into the rendered HTML so PDFs come out the same on renderers
+// without network access. It also holds the LaTeX class bundled with LaTeX
+// exports, and the screen stylesheet and Open Sans font for standalone HTML
+// exports.
+//
+//go:embed assets
+var assetFS embed.FS
+
+// assetDataURI returns the embedded asset at name, relative to the assets
+// directory, as a base64 data URI. It panics on unknown names, which can only
+// be a typo in a template.
+func assetDataURI(name string) string {
+	data, err := assetFS.ReadFile(path.Join("assets", name))
+	if err != nil {
+		panic(fmt.Sprintf("missing embedded asset %q: %v", name, err))
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mime.TypeByExtension(path.Ext(name)), base64.StdEncoding.EncodeToString(data))
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxKeywords caps how many job description terms the report scores.
+	maxKeywords = 30
+	// overuseThreshold is how often a word may appear in the CV's prose
+	// before it is reported as overused.
+	overuseThreshold = 4
+)
+
+type keywordMatch struct {
+	Keyword  string   `json:"keyword"`
+	JDCount  int      `json:"jd_count"`
+	Found    bool     `json:"found"`
+	Sections []string `json:"sections"`
+}
+
+type wordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+type keywordReport struct {
+	MatchPercentage int            `json:"match_percentage"`
+	Keywords        []keywordMatch `json:"keywords"`
+	Missing         []string       `json:"missing"`
+	Overused        []wordCount    `json:"overused"`
+}
+
+// termCounter tallies word stems, remembering the most frequent surface form
+// of each so reports show real words rather than stems.
+type termCounter struct {
+	counts map[string]int
+	forms  map[string]map[string]int
+	order  []string
+}
+
+func newTermCounter() *termCounter {
+	return &termCounter{counts: map[string]int{}, forms: map[string]map[string]int{}}
+}
+
+func (tc *termCounter) add(text string) {
+	for _, token := range tokenize(text) {
+		if isStopWord(token) {
+			continue
+		}
+		s := stem(token)
+		if _, seen := tc.counts[s]; !seen {
+			tc.order = append(tc.order, s)
+			tc.forms[s] = map[string]int{}
+		}
+		tc.counts[s]++
+		tc.forms[s][token]++
+	}
+}
+
+func (tc *termCounter) form(s string) string {
+	best, bestCount := s, 0
+	for form, count := range tc.forms[s] {
+		if count > bestCount || (count == bestCount && form < best) {
+			best, bestCount = form, count
+		}
+	}
+	return best
+}
+
+// top returns up to n stems, most frequent first and by first appearance
+// within equal counts.
+func (tc *termCounter) top(n int) []string {
+	stems := append([]string(nil), tc.order...)
+	sort.SliceStable(stems, func(i, j int) bool { return tc.counts[stems[i]] > tc.counts[stems[j]] })
+	if len(stems) > n {
+		stems = stems[:n]
+	}
+	return stems
+}
+
+// cvSections returns the searchable text of each CV section.
+func cvSections(cvData CVData) []struct{ name, text string } {
+	var experience, education, certifications strings.Builder
+	for _, exp := range cvData.Experience {
+		experience.WriteString(exp.Title + "\n" + strings.Join(texts(exp.Duties), "\n") + "\n")
+	}
+	for _, edu := range cvData.Education {
+		education.WriteString(joinNonEmpty("\n", edu.Qualification, edu.Field, edu.Institution, edu.Notes) + "\n")
+	}
+	for _, cert := range cvData.Certifications {
+		certifications.WriteString(joinNonEmpty("\n", cert.Name, cert.Issuer) + "\n")
+	}
+	return []struct{ name, text string }{
+		{"statement", cvData.Statement},
+		{"skills", strings.Join(texts(cvData.Skills), "\n")},
+		{"experience", experience.String()},
+		{"education", education.String()},
+		{"certifications", certifications.String()},
+		{"interests", strings.Join(cvData.Interests, "\n")},
+	}
+}
+
+// analyzeKeywords scores the CV against the most frequent terms of the job
+// description and flags words the CV's prose leans on too heavily.
+func analyzeKeywords(cvData CVData, jobDescription string) keywordReport {
+	jd := newTermCounter()
+	jd.add(jobDescription)
+
+	sections := cvSections(cvData)
+	sectionStems := make([]map[string]struct{}, len(sections))
+	for i, section := range sections {
+		sectionStems[i] = map[string]struct{}{}
+		for _, token := range tokenize(section.text) {
+			sectionStems[i][stem(token)] = struct{}{}
+		}
+	}
+
+	report := keywordReport{Keywords: []keywordMatch{}, Missing: []string{}, Overused: []wordCount{}}
+	found := 0
+	for _, s := range jd.top(maxKeywords) {
+		match := keywordMatch{Keyword: jd.form(s), JDCount: jd.counts[s], Sections: []string{}}
+		for i, section := range sections {
+			if _, ok := sectionStems[i][s]; ok {
+				match.Sections = append(match.Sections, section.name)
+			}
+		}
+		if match.Found = len(match.Sections) > 0; match.Found {
+			found++
+		} else {
+			report.Missing = append(report.Missing, match.Keyword)
+		}
+		report.Keywords = append(report.Keywords, match)
+	}
+	if len(report.Keywords) > 0 {
+		report.MatchPercentage = int(math.Round(100 * float64(found) / float64(len(report.Keywords))))
+	}
+
+	// Skills are a list of names, so only prose sections count towards
+	// overuse.
+	prose := newTermCounter()
+	prose.add(cvData.Statement)
+	for _, exp := range cvData.Experience {
+		for _, duty := range exp.Duties {
+			prose.add(duty.Text)
+		}
+	}
+	for _, s := range prose.top(len(prose.order)) {
+		if prose.counts[s] < overuseThreshold {
+			break
+		}
+		report.Overused = append(report.Overused, wordCount{Word: prose.form(s), Count: prose.counts[s]})
+	}
+	return report
+}
+
+func analyzeCV(c *gin.Context) {
+	var request struct {
+		CV             CVData `json:"cv"`
+		JobDescription string `json:"job_description"`
+	}
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("Error binding JSON: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if strings.TrimSpace(request.JobDescription) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Job description is required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analyzeKeywords(request.CV, request.JobDescription))
+}
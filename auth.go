@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// userAccount is someone who can sign in. Saved CVs, their share links and
+// generated downloads belong to a user.
+type userAccount struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// displayName is how the user is credited on revisions they save.
+func (u userAccount) displayName() string {
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.Email
+}
+
+// userSession and passwordReset are stored under a hash of their token, so
+// a copy of the database cannot be used to sign in.
+type userSession struct {
+	TokenHash string
+	UserID    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type passwordReset struct {
+	TokenHash string
+	UserID    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+const (
+	sessionCookie = "cv_session"
+	userKey       = "user"
+	resetTokenTTL = time.Hour
+
+	minPasswordLength = 8
+	// bcrypt ignores anything past 72 bytes.
+	maxPasswordLength = 72
+)
+
+// sessionTTL is how long a sign-in lasts, configured with SESSION_TTL.
+var sessionTTL = getEnvDuration("SESSION_TTL", 30*24*time.Hour)
+
+// registrationOpen is false when REGISTRATION=closed, for deployments whose
+// accounts have all been created.
+var registrationOpen = getEnv("REGISTRATION", "open") != "closed"
+
+// adoptCVsEmail is the account, set with ADOPT_CVS_EMAIL, that takes over CVs
+// saved before accounts existed. Without it those CVs stay unowned, so on an
+// upgraded deployment they are not handed to whoever happens to register
+// first.
+var adoptCVsEmail = strings.ToLower(strings.TrimSpace(getEnv("ADOPT_CVS_EMAIL", "")))
+
+// dummyPasswordHash is compared against when no account matches, so failed
+// sign-ins take as long whether or not the email is registered.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+}
+
+func bindCredentials(c *gin.Context, body any) bool {
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(body); err != nil {
+		log.Printf("Error binding JSON: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return false
+	}
+	return true
+}
+
+func validatePassword(password string) []fieldError {
+	switch {
+	case len(password) < minPasswordLength:
+		return []fieldError{{Field: "password", Message: "must be at least 8 characters"}}
+	case len(password) > maxPasswordLength:
+		return []fieldError{{Field: "password", Message: "must be at most 72 bytes"}}
+	}
+	return nil
+}
+
+func register(c *gin.Context) {
+	if !registrationOpen {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Registration is closed"})
+		return
+	}
+	var body credentials
+	if !bindCredentials(c, &body) {
+		return
+	}
+
+	body.Email = strings.ToLower(strings.TrimSpace(body.Email))
+	body.Name = strings.TrimSpace(body.Name)
+	var problems []fieldError
+	if address, err := mail.ParseAddress(body.Email); err != nil || address.Address != body.Email {
+		problems = append(problems, fieldError{Field: "email", Message: "must be a valid email address"})
+	}
+	problems = append(problems, validatePassword(body.Password)...)
+	if len(problems) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Invalid registration",
+			"problems": problems,
+		})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		return
+	}
+	user := userAccount{
+		ID:           uuid.New().String(),
+		Email:        body.Email,
+		Name:         body.Name,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	}
+	err = store.CreateUser(c.Request.Context(), &user)
+	if errors.Is(err, errEmailTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error saving user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		return
+	}
+
+	if user.Email == adoptCVsEmail {
+		adoptLegacyCVs(c.Request.Context())
+	}
+
+	if !startSession(c, user) {
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"user": user})
+}
+
+// adoptLegacyCVs gives unowned CVs to the ADOPT_CVS_EMAIL account if it is
+// registered. It runs at startup and when that account registers.
+func adoptLegacyCVs(ctx context.Context) {
+	if adoptCVsEmail == "" {
+		return
+	}
+	user, err := store.GetUserByEmail(ctx, adoptCVsEmail)
+	if errors.Is(err, errNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading user %s: %v", adoptCVsEmail, err)
+		return
+	}
+	adopted, err := store.AdoptCVs(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error adopting CVs: %v", err)
+		return
+	}
+	if adopted > 0 {
+		log.Printf("Gave %d CVs without an owner to %s", adopted, user.Email)
+	}
+}
+
+// signInAttempts limits wrong passwords per email and client address, so
+// account passwords cannot be guessed as fast as bcrypt allows.
+var signInAttempts = newFailureLimiter(10, 15*time.Minute)
+
+func login(c *gin.Context) {
+	var body credentials
+	if !bindCredentials(c, &body) {
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+	key := email + " " + c.ClientIP()
+	if wait := signInAttempts.retryAfter(key, time.Now()); wait > 0 {
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed sign-ins, please try again later"})
+		return
+	}
+
+	user, err := store.GetUserByEmail(c.Request.Context(), email)
+	if err != nil && !errors.Is(err, errNotFound) {
+		log.Printf("Error loading user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
+	hash := dummyPasswordHash
+	if err == nil {
+		hash = []byte(user.PasswordHash)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(body.Password)) != nil || err != nil {
+		signInAttempts.fail(key, time.Now())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		return
+	}
+	signInAttempts.reset(key)
+
+	if !startSession(c, user) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+func logout(c *gin.Context) {
+	if token, err := c.Cookie(sessionCookie); err == nil {
+		if err := store.DeleteSession(c.Request.Context(), hashToken(token)); err != nil {
+			log.Printf("Error deleting session: %v", err)
+		}
+	}
+	setSessionCookie(c, "", -1)
+	c.Status(http.StatusNoContent)
+}
+
+func getCurrentUser(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"user": currentUser(c)})
+}
+
+// Reset requests are limited per email, so an address cannot be flooded
+// with reset links, and per client address, so one client cannot work
+// through many emails. Both count every request, registered or not.
+var (
+	resetRequestsPerEmail  = newFailureLimiter(3, time.Hour)
+	resetRequestsPerClient = newFailureLimiter(10, time.Hour)
+)
+
+// requestPasswordReset sends a reset link if the email is registered. It
+// answers the same either way so it cannot be used to find accounts.
+func requestPasswordReset(c *gin.Context) {
+	var body struct {
+		Email string `json:"email"`
+	}
+	if !bindCredentials(c, &body) {
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+	now := time.Now()
+	wait := max(resetRequestsPerEmail.retryAfter(email, now), resetRequestsPerClient.retryAfter(c.ClientIP(), now))
+	if wait > 0 {
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many reset requests, please try again later"})
+		return
+	}
+	resetRequestsPerEmail.fail(email, now)
+	resetRequestsPerClient.fail(c.ClientIP(), now)
+
+	// The lookup and the email happen after the response, so registered and
+	// unknown emails take the same time to answer.
+	pendingMail.Add(1)
+	go func() {
+		defer pendingMail.Done()
+		if err := sendPasswordReset(email); err != nil {
+			log.Printf("Error sending password reset: %v", err)
+		}
+	}()
+	c.Status(http.StatusAccepted)
+}
+
+// pendingMail tracks reset emails still being sent, so shutdown can wait for
+// them before closing the store.
+var pendingMail sync.WaitGroup
+
+// sendPasswordReset emails a reset link to the account registered with email,
+// if there is one.
+func sendPasswordReset(email string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	user, err := store.GetUserByEmail(ctx, email)
+	if errors.Is(err, errNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	reset := passwordReset{TokenHash: hashToken(token), UserID: user.ID, CreatedAt: now, ExpiresAt: now.Add(resetTokenTTL)}
+	if err := store.CreatePasswordReset(ctx, reset); err != nil {
+		return err
+	}
+
+	link := publicURL + "/?reset_token=" + token
+	return mailer.Send(user.Email, "Reset your CV Builder password",
+		"Someone asked to reset the password for your CV Builder account.\n\n"+
+			"To choose a new password, open this link within an hour:\n\n"+link+"\n\n"+
+			"If it was not you, you can ignore this email.\n")
+}
+
+// resetPassword sets a new password using the token from a reset link. All
+// of the user's sessions end, so they sign in again with the new password.
+func resetPassword(c *gin.Context) {
+	var body struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if !bindCredentials(c, &body) {
+		return
+	}
+	if problems := validatePassword(body.Password); len(problems) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Invalid password",
+			"problems": problems,
+		})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	err = store.ResetPassword(c.Request.Context(), hashToken(body.Token), string(hash), time.Now().UTC())
+	if errors.Is(err, errNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reset link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error resetting password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// requireUser rejects requests without a valid session and makes the user
+// available to handlers through currentUser.
+func requireUser(c *gin.Context) {
+	token, err := c.Cookie(sessionCookie)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sign in required"})
+		return
+	}
+	user, expiresAt, err := store.SessionUser(c.Request.Context(), hashToken(token))
+	if errors.Is(err, errNotFound) || (err == nil && !time.Now().Before(expiresAt)) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sign in required"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading session: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to load session"})
+		return
+	}
+	c.Set(userKey, user)
+	c.Next()
+}
+
+// currentUser is the signed-in user. Only call it behind requireUser.
+func currentUser(c *gin.Context) userAccount {
+	return c.MustGet(userKey).(userAccount)
+}
+
+func startSession(c *gin.Context, user userAccount) bool {
+	token, err := newSecretToken()
+	if err == nil {
+		now := time.Now().UTC()
+		err = store.CreateSession(c.Request.Context(), userSession{
+			TokenHash: hashToken(token),
+			UserID:    user.ID,
+			CreatedAt: now,
+			ExpiresAt: now.Add(sessionTTL),
+		})
+	}
+	if err != nil {
+		log.Printf("Error starting session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return false
+	}
+	setSessionCookie(c, token, int(sessionTTL.Seconds()))
+	return true
+}
+
+// setSessionCookie keeps the session out of reach of scripts and of
+// cross-site form posts.
+func setSessionCookie(c *gin.Context, token string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookie, token, maxAge, "/", "", isHTTPS(c), true)
+}
+
+// newSecretToken returns 32 random bytes, URL-safe encoded.
+func newSecretToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func authRouter() *gin.Engine {
+	r := gin.New()
+	auth := r.Group("/api/auth")
+	auth.POST("/register", register)
+	auth.POST("/login", login)
+	auth.POST("/logout", logout)
+	auth.POST("/password-reset", requestPasswordReset)
+	auth.POST("/password-reset/confirm", resetPassword)
+	auth.GET("/me", requireUser, getCurrentUser)
+
+	api := r.Group("/api", requireUser)
+	api.GET("/cvs", listCVs)
+	cv := api.Group("/cvs/:id", requireCVOwner)
+	cv.GET("", getCV)
+	return r
+}
+
+func postJSON(path, body string, cookies ...*http.Cookie) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func getWith(path string, cookies ...*http.Cookie) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+// sessionCookieOf returns the session cookie set by a response.
+func sessionCookieOf(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == sessionCookie && cookie.Value != "" {
+			return cookie
+		}
+	}
+	t.Fatalf("no session cookie in %d response %s", w.Code, w.Body)
+	return nil
+}
+
+func TestRegisterAndSignIn(t *testing.T) {
+	useTestStore(t)
+	r := authRouter()
+
+	w := serve(r, postJSON("/api/auth/register", `{"email":" Jane@Example.com ","password":"correct horse","name":"Jane"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: %d %s", w.Code, w.Body)
+	}
+	cookie := sessionCookieOf(t, w)
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("session cookie = %+v, want HttpOnly and SameSite=Lax", cookie)
+	}
+	if strings.Contains(w.Body.String(), "password") {
+		t.Errorf("response leaks the password hash: %s", w.Body)
+	}
+
+	w = serve(r, getWith("/api/auth/me", cookie))
+	var me struct{ User userAccount }
+	json.Unmarshal(w.Body.Bytes(), &me)
+	if w.Code != http.StatusOK || me.User.Email != "jane@example.com" {
+		t.Errorf("me: %d %s", w.Code, w.Body)
+	}
+
+	if w := serve(r, postJSON("/api/auth/register", `{"email":"jane@example.com","password":"another one"}`)); w.Code != http.StatusConflict {
+		t.Errorf("duplicate email: %d, want 409", w.Code)
+	}
+	if w := serve(r, postJSON("/api/auth/login", `{"email":"jane@example.com","password":"wrong password"}`)); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: %d, want 401", w.Code)
+	}
+	if w := serve(r, postJSON("/api/auth/login", `{"email":"nobody@example.com","password":"correct horse"}`)); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown email: %d, want 401", w.Code)
+	}
+
+	w = serve(r, postJSON("/api/auth/login", `{"email":"jane@example.com","password":"correct horse"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: %d %s", w.Code, w.Body)
+	}
+	second := sessionCookieOf(t, w)
+
+	if w := serve(r, postJSON("/api/auth/logout", "", second)); w.Code != http.StatusNoContent {
+		t.Errorf("logout: %d", w.Code)
+	}
+	if w := serve(r, getWith("/api/auth/me", second)); w.Code != http.StatusUnauthorized {
+		t.Errorf("signed-out session still works: %d", w.Code)
+	}
+	if w := serve(r, getWith("/api/auth/me", cookie)); w.Code != http.StatusOK {
+		t.Errorf("signing out ended another session: %d", w.Code)
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	useTestStore(t)
+	r := authRouter()
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"bad email", `{"email":"not an email","password":"long enough"}`, http.StatusUnprocessableEntity},
+		{"display name in email", `{"email":"Jane <jane@example.com>","password":"long enough"}`, http.StatusUnprocessableEntity},
+		{"short password", `{"email":"jane@example.com","password":"short"}`, http.StatusUnprocessableEntity},
+		{"long password", `{"email":"jane@example.com","password":"` + strings.Repeat("a", 73) + `"}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"email":"jane@example.com","password":"long enough","admin":true}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := serve(r, postJSON("/api/auth/register", tt.body)); w.Code != tt.want {
+			t.Errorf("%s: %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+
+	previous := registrationOpen
+	registrationOpen = false
+	t.Cleanup(func() { registrationOpen = previous })
+	if w := serve(r, postJSON("/api/auth/register", `{"email":"jane@example.com","password":"long enough"}`)); w.Code != http.StatusForbidden {
+		t.Errorf("closed registration: %d, want 403", w.Code)
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	useTestStore(t)
+	r := authRouter()
+	user := newTestUser(t, "jane@example.com", "password")
+
+	if w := serve(r, getWith("/api/cvs")); w.Code != http.StatusUnauthorized {
+		t.Errorf("no cookie: %d, want 401", w.Code)
+	}
+	if w := serve(r, getWith("/api/cvs", &http.Cookie{Name: sessionCookie, Value: "forged"})); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown session: %d, want 401", w.Code)
+	}
+
+	token, _ := newSecretToken()
+	expired := userSession{TokenHash: hashToken(token), UserID: user.ID, CreatedAt: time.Now().Add(-2 * time.Hour), ExpiresAt: time.Now().Add(-time.Hour)}
+	if err := store.CreateSession(context.Background(), expired); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(r, getWith("/api/cvs", &http.Cookie{Name: sessionCookie, Value: token})); w.Code != http.StatusUnauthorized {
+		t.Errorf("expired session: %d, want 401", w.Code)
+	}
+	// Only the hash of a session token is stored, so the hash itself does
+	// not sign anyone in.
+	if w := serve(r, getWith("/api/cvs", &http.Cookie{Name: sessionCookie, Value: hashToken(token)})); w.Code != http.StatusUnauthorized {
+		t.Errorf("stored hash accepted as a token: %d, want 401", w.Code)
+	}
+}
+
+func TestCVsAreScopedToTheirOwner(t *testing.T) {
+	useTestStore(t)
+	r := authRouter()
+	jane := newTestUser(t, "jane@example.com", "password")
+	john := newTestUser(t, "john@example.com", "password")
+	janeCV := newTestCV(t, jane, "Jane Doe")
+	newTestCV(t, john, "John Smith")
+
+	w := serve(r, postJSON("/api/auth/login", `{"email":"john@example.com","password":"password"}`))
+	cookie := sessionCookieOf(t, w)
+
+	w = serve(r, getWith("/api/cvs", cookie))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Jane Doe") || !strings.Contains(w.Body.String(), "John Smith") {
+		t.Errorf("list: %d %s", w.Code, w.Body)
+	}
+	if w := serve(r, getWith("/api/cvs/"+janeCV.ID, cookie)); w.Code != http.StatusNotFound {
+		t.Errorf("another user's CV: %d, want 404", w.Code)
+	}
+	if w := serve(r, getWith("/api/cvs/missing", cookie)); w.Code != http.StatusNotFound {
+		t.Errorf("missing CV: %d, want 404", w.Code)
+	}
+}
+
+// insertLegacyCV saves a CV without an owner, as CVs saved before accounts
+// existed are.
+func insertLegacyCV(t *testing.T, id, name string) {
+	t.Helper()
+	_, err := store.(*sqliteStore).db.ExecContext(context.Background(),
+		"INSERT INTO cvs (id, owner_id, data, created_at, updated_at, revision) VALUES (?, NULL, json_object('name', ?), ?, ?, 1)",
+		id, name, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpgradeLeavesLegacyCVsUnowned(t *testing.T) {
+	useTestStore(t)
+	r := authRouter()
+	insertLegacyCV(t, "legacy-1", "Team Member One")
+	insertLegacyCV(t, "legacy-2", "Team Member Two")
+
+	var cookies []*http.Cookie
+	for _, email := range []string{"first@example.com", "second@example.com"} {
+		w := serve(r, postJSON("/api/auth/register", `{"email":"`+email+`","password":"long enough"}`))
+		cookies = append(cookies, sessionCookieOf(t, w))
+	}
+	for i, cookie := range cookies {
+		if w := serve(r, getWith("/api/cvs", cookie)); strings.Contains(w.Body.String(), "Team Member") {
+			t.Errorf("user %d lists legacy CVs: %s", i+1, w.Body)
+		}
+		if w := serve(r, getWith("/api/cvs/legacy-1", cookie)); w.Code != http.StatusNotFound {
+			t.Errorf("user %d opened a legacy CV: %d, want 404", i+1, w.Code)
+		}
+	}
+	if owner, err := store.CVOwner(context.Background(), "legacy-1"); err != nil || owner != "" {
+		t.Errorf("legacy CV owner = %q, %v; want none", owner, err)
+	}
+}
+
+func TestAdoptCVsEmailTakesOverLegacyCVs(t *testing.T) {
+	useTestStore(t)
+	previous := adoptCVsEmail
+	adoptCVsEmail = "admin@example.com"
+	t.Cleanup(func() { adoptCVsEmail = previous })
+	r := authRouter()
+	ctx := context.Background()
+	insertLegacyCV(t, "legacy-1", "Team Member One")
+
+	serve(r, postJSON("/api/auth/register", `{"email":"first@example.com","password":"long enough"}`))
+	if owner, _ := store.CVOwner(ctx, "legacy-1"); owner != "" {
+		t.Fatalf("legacy CV given to the first user to register")
+	}
+
+	w := serve(r, postJSON("/api/auth/register", `{"email":"Admin@Example.com","password":"long enough"}`))
+	admin := sessionCookieOf(t, w)
+	if w := serve(r, getWith("/api/cvs/legacy-1", admin)); w.Code != http.StatusOK {
+		t.Errorf("configured account cannot open the legacy CV: %d", w.Code)
+	}
+
+	// At startup, CVs are adopted by the account if it already exists.
+	insertLegacyCV(t, "legacy-2", "Team Member Two")
+	adoptLegacyCVs(ctx)
+	if w := serve(r, getWith("/api/cvs/legacy-2", admin)); w.Code != http.StatusOK {
+		t.Errorf("legacy CV not adopted at startup: %d", w.Code)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	useTestStore(t)
+	r := authRouter()
+	user := newTestUser(t, "jane@example.com", "old password")
+	old := sessionCookieOf(t, serve(r, postJSON("/api/auth/login", `{"email":"jane@example.com","password":"old password"}`)))
+
+	var logged bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&logged)
+	for _, email := range []string{"jane@example.com", "nobody@example.com"} {
+		if w := serve(r, postJSON("/api/auth/password-reset", `{"email":"`+email+`"}`)); w.Code != http.StatusAccepted {
+			t.Errorf("reset request for %s: %d, want 202", email, w.Code)
+		}
+	}
+	pendingMail.Wait()
+	log.SetOutput(previous)
+	if !strings.Contains(logged.String(), "jane@example.com") || strings.Contains(logged.String(), "nobody@example.com") {
+		t.Errorf("reset emails logged as %q, want one to the registered address only", logged.String())
+	}
+
+	token, _ := newSecretToken()
+	now := time.Now().UTC()
+	reset := passwordReset{TokenHash: hashToken(token), UserID: user.ID, CreatedAt: now, ExpiresAt: now.Add(resetTokenTTL)}
+	if err := store.CreatePasswordReset(context.Background(), reset); err != nil {
+		t.Fatal(err)
+	}
+	confirm := func(token, password string) int {
+		return serve(r, postJSON("/api/auth/password-reset/confirm", `{"token":"`+token+`","password":"`+password+`"}`)).Code
+	}
+
+	if code := confirm(token, "short"); code != http.StatusUnprocessableEntity {
+		t.Errorf("short password: %d, want 422", code)
+	}
+	if code := confirm("forged", "new password"); code != http.StatusBadRequest {
+		t.Errorf("unknown token: %d, want 400", code)
+	}
+	if code := confirm(token, "new password"); code != http.StatusNoContent {
+		t.Fatalf("reset: %d, want 204", code)
+	}
+	if code := confirm(token, "newer password"); code != http.StatusBadRequest {
+		t.Errorf("token reused: %d, want 400", code)
+	}
+
+	if w := serve(r, getWith("/api/auth/me", old)); w.Code != http.StatusUnauthorized {
+		t.Errorf("session from before the reset still works: %d", w.Code)
+	}
+	if w := serve(r, postJSON("/api/auth/login", `{"email":"jane@example.com","password":"old password"}`)); w.Code != http.StatusUnauthorized {
+		t.Errorf("old password still works: %d", w.Code)
+	}
+	if w := serve(r, postJSON("/api/auth/login", `{"email":"jane@example.com","password":"new password"}`)); w.Code != http.StatusOK {
+		t.Errorf("new password: %d", w.Code)
+	}
+
+	expiredToken, _ := newSecretToken()
+	expired := passwordReset{TokenHash: hashToken(expiredToken), UserID: user.ID, CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	if err := store.CreatePasswordReset(context.Background(), expired); err != nil {
+		t.Fatal(err)
+	}
+	if code := confirm(expiredToken, "new password"); code != http.StatusBadRequest {
+		t.Errorf("expired token: %d, want 400", code)
+	}
+}
+
+func TestMailerDoesNotLogBodies(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(previous) })
+
+	if err := (smtpMailer{}).Send("jane@example.com", "Reset", "secret link"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "secret link") || !strings.Contains(buf.String(), "jane@example.com") {
+		t.Errorf("log = %q, want the recipient but not the body", buf.String())
+	}
+
+	buf.Reset()
+	if err := (smtpMailer{logBody: true}).Send("jane@example.com", "Reset", "secret link"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "secret link") {
+		t.Errorf("log = %q, want the body with MAIL_LOG_BODY", buf.String())
+	}
+}
+
+func TestLoginLimitsAttempts(t *testing.T) {
+	useTestStore(t)
+	previous := signInAttempts
+	signInAttempts = newFailureLimiter(3, time.Minute)
+	t.Cleanup(func() { signInAttempts = previous })
+	r := authRouter()
+	newTestUser(t, "jane@example.com", "right password")
+
+	for i := 0; i < 3; i++ {
+		if w := serve(r, postJSON("/api/auth/login", `{"email":"jane@example.com","password":"wrong password"}`)); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: %d, want 401", i+1, w.Code)
+		}
+	}
+	w := serve(r, postJSON("/api/auth/login", `{"email":"JANE@example.com","password":"right password"}`))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("after the limit: %d with Retry-After %q, want 429", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	req := postJSON("/api/auth/login", `{"email":"jane@example.com","password":"right password"}`)
+	req.RemoteAddr = "192.0.2.99:1234"
+	if w := serve(r, req); w.Code != http.StatusOK {
+		t.Errorf("another address: %d, want 200", w.Code)
+	}
+}
+
+func TestPasswordResetRequestsAreLimited(t *testing.T) {
+	useTestStore(t)
+	previousEmail, previousClient := resetRequestsPerEmail, resetRequestsPerClient
+	resetRequestsPerEmail, resetRequestsPerClient = newFailureLimiter(2, time.Hour), newFailureLimiter(3, time.Hour)
+	t.Cleanup(func() { resetRequestsPerEmail, resetRequestsPerClient = previousEmail, previousClient })
+	t.Cleanup(pendingMail.Wait)
+	r := authRouter()
+
+	request := func(email, remote string) *httptest.ResponseRecorder {
+		req := postJSON("/api/auth/password-reset", `{"email":"`+email+`"}`)
+		req.RemoteAddr = remote
+		return serve(r, req)
+	}
+	for i := 0; i < 2; i++ {
+		if w := request("nobody@example.com", "192.0.2.1:1"); w.Code != http.StatusAccepted {
+			t.Fatalf("request %d: %d, want 202", i+1, w.Code)
+		}
+	}
+	if w := request("Nobody@example.com", "192.0.2.2:1"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("same email from another address: %d, want 429", w.Code)
+	}
+	if w := request("other@example.com", "192.0.2.1:1"); w.Code != http.StatusAccepted {
+		t.Errorf("third request from an address: %d, want 202", w.Code)
+	}
+	if w := request("third@example.com", "192.0.2.1:1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("fourth request from an address: %d, want 429", w.Code)
+	}
+}
+
+func TestPasswordResetAnswersBeforeSending(t *testing.T) {
+	useTestStore(t)
+	r := authRouter()
+	newTestUser(t, "jane@example.com", "password")
+	// Even when the reset cannot be saved, the response does not reveal that
+	// the email is registered.
+	store.Close()
+	if w := serve(r, postJSON("/api/auth/password-reset", `{"email":"jane@example.com"}`)); w.Code != http.StatusAccepted {
+		t.Errorf("failed reset: %d, want 202", w.Code)
+	}
+	pendingMail.Wait()
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// basicRenderer is a dependency-free fallback that lays out the text of the
+// HTML document with the standard PDF Helvetica fonts. It ignores CSS, so the
+// output is plain, but it needs neither Gotenberg nor a browser.
+type basicRenderer struct{}
+
+func (basicRenderer) Name() string {
+	return "basic"
+}
+
+func (basicRenderer) Render(ctx context.Context, r io.Reader) (io.ReadCloser, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("parsing HTML: %w", err)
+	}
+	blocks := collectTextBlocks(doc)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(layoutBasicPDF(blocks))), nil
+}
+
+type textBlock struct {
+	size   float64
+	bold   bool
+	indent float64
+	text   string
+}
+
+var basicBlockStyles = map[string]textBlock{
+	"h1": {size: 22, bold: true},
+	"h2": {size: 14, bold: true},
+	"h3": {size: 12, bold: true},
+	"li": {size: 10, indent: 14},
+}
+
+// collectTextBlocks flattens the document body into styled paragraphs, one
+// per block-level element.
+func collectTextBlocks(doc *html.Node) []textBlock {
+	var blocks []textBlock
+	var text strings.Builder
+	style := textBlock{size: 10}
+
+	flush := func() {
+		if t := strings.Join(strings.Fields(text.String()), " "); t != "" {
+			block := style
+			block.text = t
+			blocks = append(blocks, block)
+		}
+		text.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			text.WriteString(n.Data)
+			text.WriteString(" ")
+			return
+		case html.ElementNode:
+			switch n.Data {
+			case "head", "style", "script":
+				return
+			case "div", "p", "section", "h1", "h2", "h3", "li", "ul", "br":
+				flush()
+				previous := style
+				if s, ok := basicBlockStyles[n.Data]; ok {
+					style = s
+				}
+				for _, attr := range n.Attr {
+					if attr.Key == "id" && attr.Val == "name" {
+						style = basicBlockStyles["h1"]
+					}
+				}
+				if n.Data == "li" {
+					text.WriteString("• ")
+				}
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					walk(c)
+				}
+				flush()
+				style = previous
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	flush()
+	return blocks
+}
+
+const (
+	basicPageWidth  = 595.0
+	basicPageHeight = 842.0
+	basicMargin     = 50.0
+)
+
+// layoutBasicPDF word-wraps the blocks onto A4 pages and serialises them as
+// a minimal PDF 1.4 file.
+func layoutBasicPDF(blocks []textBlock) []byte {
+	var pages []*bytes.Buffer
+	page := &bytes.Buffer{}
+	pages = append(pages, page)
+	y := basicPageHeight - basicMargin
+
+	for _, block := range blocks {
+		lineHeight := block.size * 1.3
+		if block.bold {
+			y -= block.size * 0.6
+		}
+		width := basicPageWidth - 2*basicMargin - block.indent
+		for _, line := range wrapText(block.text, block.size, width) {
+			if y-lineHeight < basicMargin {
+				page = &bytes.Buffer{}
+				pages = append(pages, page)
+				y = basicPageHeight - basicMargin
+			}
+			y -= lineHeight
+			font := "F1"
+			if block.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+				font, block.size, basicMargin+block.indent, y, pdfEscape(line))
+		}
+		y -= block.size * 0.3
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	// Objects 1-4 are the catalog, page tree and fonts; each page then takes
+	// two objects, the page itself followed by its content stream.
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, p := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			basicPageWidth, basicPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.Len(), p.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// wrapText breaks text into lines no wider than width, estimating glyph
+// widths from Helvetica's average advance.
+func wrapText(text string, size, width float64) []string {
+	maxChars := int(width / (size * 0.5))
+	var lines []string
+	var line strings.Builder
+	for _, word := range strings.Fields(text) {
+		if line.Len() > 0 && line.Len()+1+len(word) > maxChars {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(word)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// winAnsiExtras are the characters WinAnsiEncoding places in 0x80–0x9F,
+// where Latin-1 has control codes.
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// pdfEscape encodes s as the body of a PDF literal string in WinAnsi,
+// replacing characters the encoding lacks with '?'.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r >= 160 && r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case winAnsiExtras[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", winAnsiExtras[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
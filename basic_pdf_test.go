@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestPDFEscape(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Plain (text) \\ here", `Plain \(text\) \\ here`},
+		{"Café", `Caf\351`},
+		{"2019 – 2021 — “Lead” • €5k’s", `2019 \226 2021 \227 \223Lead\224 \225 \2005k\222s`},
+		{"tab\there", "tab here"},
+		{"\u0085 control, 漢字", "? control, ??"},
+	}
+	for _, tt := range tests {
+		if got := pdfEscape(tt.in); got != tt.want {
+			t.Errorf("pdfEscape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"time"
+)
+
+var certificationDateLayouts = []string{"2006-01-02", "2006-01", "2006"}
+
+// parseCertificationDate parses an issue or expiry date and returns the last
+// instant it covers, so a credential expiring "2024-03" is valid all March.
+func parseCertificationDate(value string) (time.Time, bool) {
+	for _, layout := range certificationDateLayouts {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		switch layout {
+		case "2006-01-02":
+			return t.AddDate(0, 0, 1), true
+		case "2006-01":
+			return t.AddDate(0, 1, 0), true
+		default:
+			return t.AddDate(1, 0, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Expired reports whether the credential's expiry date lies before now.
+// Credentials without a parseable expiry date never expire.
+func (c Certification) Expired(now time.Time) bool {
+	end, ok := parseCertificationDate(c.Expires)
+	return ok && !now.Before(end)
+}
+
+func (c Certification) Dates(layout string) string {
+	switch {
+	case c.Issued != "" && c.Expires != "":
+		return "Issued " + formatDate(c.Issued, layout) + ", expires " + formatDate(c.Expires, layout)
+	case c.Issued != "":
+		return "Issued " + formatDate(c.Issued, layout)
+	case c.Expires != "":
+		return "Expires " + formatDate(c.Expires, layout)
+	}
+	return ""
+}
+
+func (c Certification) Details(layout string) string {
+	credential := ""
+	if c.CredentialID != "" {
+		credential = "Credential ID " + c.CredentialID
+	}
+	return joinNonEmpty(" | ", c.Issuer, c.Dates(layout), credential)
+}
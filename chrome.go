@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// chromeRenderer prints PDFs with a locally installed headless Chrome or
+// Chromium, for deployments without a Gotenberg container.
+type chromeRenderer struct {
+	binary string
+	// noSandbox turns off Chrome's sandbox, which cannot start when running
+	// as root in a container. Elsewhere it should stay on, since the pages
+	// rendered contain user input.
+	noSandbox bool
+}
+
+var chromeBinaries = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"}
+
+func newChromeRenderer(binary string, noSandbox bool) (*chromeRenderer, error) {
+	if binary != "" {
+		path, err := exec.LookPath(binary)
+		if err != nil {
+			return nil, fmt.Errorf("chrome binary %q not found: %w", binary, err)
+		}
+		return &chromeRenderer{binary: path, noSandbox: noSandbox}, nil
+	}
+	for _, name := range chromeBinaries {
+		if path, err := exec.LookPath(name); err == nil {
+			return &chromeRenderer{binary: path, noSandbox: noSandbox}, nil
+		}
+	}
+	return nil, errors.New("no Chrome or Chromium binary found, set CHROME_PATH")
+}
+
+func (r *chromeRenderer) Name() string {
+	return "chrome"
+}
+
+func (r *chromeRenderer) Render(ctx context.Context, html io.Reader) (io.ReadCloser, error) {
+	dir, err := os.MkdirTemp("", "cv-chrome-")
+	if err != nil {
+		return nil, fmt.Errorf("creating work directory: %w", err)
+	}
+
+	input := filepath.Join(dir, "index.html")
+	output := filepath.Join(dir, "cv.pdf")
+	if err := writeFileFrom(input, html); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("writing HTML: %w", err)
+	}
+
+	args := []string{
+		"--headless",
+		"--disable-gpu",
+		"--no-pdf-header-footer",
+		"--user-data-dir=" + filepath.Join(dir, "profile"),
+		"--print-to-pdf=" + output,
+	}
+	if r.noSandbox {
+		args = append(args, "--no-sandbox")
+	}
+	cmd := exec.CommandContext(ctx, r.binary, append(args, "file://"+input)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("running %s: %w: %s", r.binary, err, out)
+	}
+
+	pdf, err := os.Open(output)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("opening PDF: %w", err)
+	}
+	return &removeOnClose{File: pdf, dir: dir}, nil
+}
+
+func writeFileFrom(path string, r io.Reader) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// removeOnClose deletes the renderer's work directory once the PDF has been
+// read.
+type removeOnClose struct {
+	*os.File
+	dir string
+}
+
+func (r *removeOnClose) Close() error {
+	err := r.File.Close()
+	os.RemoveAll(r.dir)
+	return err
+}
@@ -0,0 +1,199 @@
+// Package cvmarkdown reads and writes CVs authored in Markdown, so they can be
+// kept in a repository alongside other documents.
+//
+// A document follows this convention:
+//
+//	---
+//	address: 1 High Street, Leeds
+//	phone1: 07700 900000
+//	email: jane@example.com
+//	---
+//
+//	# Jane Doe
+//
+//	## Summary
+//
+//	Free text, kept line for line.
+//
+//	## Skills
+//
+//	- Go {tags: backend, cloud}
+//	- Public speaking
+//
+//	## Experience
+//
+//	### Senior Engineer
+//	company: Acme Ltd
+//	start: 2020-01
+//	end: present
+//	tags: backend
+//
+//	- Led the move to Kubernetes
+//
+//	## Education
+//
+//	### University of Leeds
+//	qualification: BSc
+//	field: Computer Science
+//	start: 2012
+//	end: 2015
+//
+//	Notes follow the fields after a blank line.
+//
+//	## Certifications
+//
+//	### Certified Kubernetes Administrator
+//	issuer: CNCF
+//	issued: 2023-04
+//
+//	## Interests
+//
+//	- Climbing
+//
+// The optional front matter holds the contact fields and the single "#"
+// heading holds the name. Each "##" heading opens one of the sections shown;
+// "Profile" and "Work Experience" are accepted as aliases, and section names
+// are case-insensitive. Skills and Interests are bullet lists. Experience,
+// Education and Certifications hold "###" entries whose "key: value" fields
+// directly follow the heading. Any list item may end with "{tags: a, b}".
+// A text line that would otherwise be read as a heading, list item or field
+// can be escaped with a leading backslash, as can the brace of an item whose
+// text itself ends in "{tags: ...}".
+//
+// Parse checks the structure and reports problems as *Error values carrying
+// the line number. Interpreting field values, such as dates, is left to the
+// caller, which can use the Line of each Field, Item and Entry to report its
+// own errors the same way.
+package cvmarkdown
+
+import "fmt"
+
+// Canonical section names, as written by Bytes and reported by Parse.
+const (
+	Summary        = "Summary"
+	Skills         = "Skills"
+	Experience     = "Experience"
+	Education      = "Education"
+	Certifications = "Certifications"
+	Interests      = "Interests"
+)
+
+// ContactFields are the keys allowed in the front matter.
+var ContactFields = []string{"address", "phone1", "phone2", "email"}
+
+// Document is a parsed CV. Sections are kept in document order and hold
+// only what was written; mapping them onto a CV is left to the caller.
+type Document struct {
+	Name        string
+	NameLine    int
+	FrontMatter Fields
+	Sections    []Section
+}
+
+// Section returns the named section, or nil when the document has none.
+func (d *Document) Section(name string) *Section {
+	for i := range d.Sections {
+		if d.Sections[i].Name == name {
+			return &d.Sections[i]
+		}
+	}
+	return nil
+}
+
+// Section is a "##" section. Depending on its kind it holds free text,
+// list items or "###" entries.
+type Section struct {
+	Name    string
+	Line    int
+	Text    string
+	Items   []Item
+	Entries []Entry
+}
+
+// Entry is a "###" entry, such as one role in the Experience section,
+// with its fields, optional text and list items.
+type Entry struct {
+	Heading string
+	Line    int
+	Fields  Fields
+	Text    string
+	Items   []Item
+}
+
+// Field is a "key: value" line of the front matter or of an entry.
+type Field struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+// Fields are kept in the order they were written.
+type Fields []Field
+
+// Lookup returns the field with the given key.
+func (fs Fields) Lookup(key string) (Field, bool) {
+	for _, f := range fs {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Get returns the value of the field with the given key, or "" when absent.
+func (fs Fields) Get(key string) string {
+	f, _ := fs.Lookup(key)
+	return f.Value
+}
+
+// Item is a list item with the tags from its "{tags: ...}" suffix.
+type Item struct {
+	Text string
+	Tags []string
+	Line int
+}
+
+// Error is a problem with the document at a 1-based line number.
+type Error struct {
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+func errorf(line int, format string, args ...any) *Error {
+	return &Error{Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+type sectionKind int
+
+const (
+	textSection sectionKind = iota
+	listSection
+	entrySection
+)
+
+// sectionSpec describes what a section may contain. Entry sections list the
+// fields their entries accept and whether entries take text or list items.
+type sectionSpec struct {
+	name       string
+	aliases    []string
+	kind       sectionKind
+	fields     []string
+	entryText  bool
+	entryItems bool
+}
+
+var sectionSpecs = []sectionSpec{
+	{name: Summary, aliases: []string{"profile", "statement"}, kind: textSection},
+	{name: Skills, kind: listSection},
+	{name: Experience, aliases: []string{"work experience"}, kind: entrySection,
+		fields: []string{"company", "start", "end", "period", "tags"}, entryItems: true},
+	{name: Education, kind: entrySection,
+		fields: []string{"qualification", "field", "start", "end", "grade"}, entryText: true},
+	{name: Certifications, kind: entrySection,
+		fields: []string{"issuer", "credential_id", "issued", "expires", "url"}},
+	{name: Interests, kind: listSection},
+}
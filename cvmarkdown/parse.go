@@ -0,0 +1,296 @@
+package cvmarkdown
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	fieldLine  = regexp.MustCompile(`^([a-z][a-z0-9_]*):(?:\s+(.*))?$`)
+	itemTags   = regexp.MustCompile(`\s*(\\?)\{tags:([^}]*)\}$`)
+	listMarker = regexp.MustCompile(`^[-*+](\s+|$)`)
+)
+
+// Parse reads a document written in the package convention.
+func Parse(data []byte) (*Document, error) {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	p := &parser{doc: &Document{}}
+	start, err := p.frontMatter(lines)
+	if err != nil {
+		return nil, err
+	}
+	for i := start; i < len(lines); i++ {
+		if err := p.line(lines[i], i+1); err != nil {
+			return nil, err
+		}
+	}
+	p.flushText()
+	if p.doc.NameLine == 0 {
+		return nil, errorf(1, "missing \"# Name\" heading")
+	}
+	return p.doc, nil
+}
+
+type parser struct {
+	doc  *Document
+	spec *sectionSpec
+	// inFields is set directly after an entry heading, while lines are
+	// still read as "key: value" fields.
+	inFields bool
+	// inItem is set while indented lines continue the last list item.
+	inItem bool
+	text   []string
+}
+
+// frontMatter reads the optional contact fields and returns the index of the
+// first line after them.
+func (p *parser) frontMatter(lines []string) (int, error) {
+	if strings.TrimSpace(lines[0]) != "---" {
+		return 0, nil
+	}
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "---":
+			return i + 1, nil
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		}
+		f, ok := parseField(line, i+1)
+		if !ok {
+			return 0, errorf(i+1, "expected \"key: value\" in front matter")
+		}
+		if !slices.Contains(ContactFields, f.Key) {
+			return 0, errorf(i+1, "unknown front matter field %q", f.Key)
+		}
+		if prev, dup := p.doc.FrontMatter.Lookup(f.Key); dup {
+			return 0, errorf(i+1, "field %q is already set on line %d", f.Key, prev.Line)
+		}
+		p.doc.FrontMatter = append(p.doc.FrontMatter, f)
+	}
+	return 0, errorf(1, "front matter is not closed with \"---\"")
+}
+
+func (p *parser) line(raw string, n int) error {
+	line := strings.TrimSpace(raw)
+	if line == "" {
+		p.inFields, p.inItem = false, false
+		p.text = append(p.text, "")
+		return nil
+	}
+	if p.inItem && (raw[0] == ' ' || raw[0] == '\t') {
+		items := p.items()
+		item := &(*items)[len(*items)-1]
+		item.Text += " " + line
+		return nil
+	}
+	p.inItem = false
+
+	if level, heading, ok := parseHeading(line); ok {
+		p.inFields = false
+		return p.heading(level, heading, n)
+	}
+	if listMarker.MatchString(line) {
+		p.inFields = false
+		return p.item(strings.TrimSpace(line[1:]), n)
+	}
+	if p.inFields {
+		if f, ok := parseField(line, n); ok {
+			return p.field(f)
+		}
+		p.inFields = false
+	}
+	return p.textLine(strings.TrimPrefix(line, `\`), n)
+}
+
+func (p *parser) heading(level int, text string, n int) error {
+	switch level {
+	case 1:
+		if p.doc.NameLine != 0 {
+			return errorf(n, "the name is already set on line %d", p.doc.NameLine)
+		}
+		if p.spec != nil {
+			return errorf(n, "the \"# Name\" heading must come before any section")
+		}
+		if text == "" {
+			return errorf(n, "the name is empty")
+		}
+		p.doc.Name, p.doc.NameLine = text, n
+		p.text = nil
+		return nil
+	case 2:
+		if p.doc.NameLine == 0 {
+			return errorf(n, "expected \"# Name\" heading before any section")
+		}
+		spec := lookupSection(text)
+		if spec == nil {
+			return errorf(n, "unknown section %q", text)
+		}
+		if prev := p.doc.Section(spec.name); prev != nil {
+			return errorf(n, "section %q is already defined on line %d", spec.name, prev.Line)
+		}
+		p.flushText()
+		p.spec = spec
+		p.doc.Sections = append(p.doc.Sections, Section{Name: spec.name, Line: n})
+		return nil
+	case 3:
+		if p.spec == nil || p.spec.kind != entrySection {
+			return errorf(n, "\"###\" entries are only allowed in the %s, %s and %s sections", Experience, Education, Certifications)
+		}
+		if text == "" {
+			return errorf(n, "the entry heading is empty")
+		}
+		p.flushText()
+		section := p.section()
+		section.Entries = append(section.Entries, Entry{Heading: text, Line: n})
+		p.inFields = true
+		return nil
+	default:
+		return errorf(n, "headings deeper than \"###\" are not supported")
+	}
+}
+
+func (p *parser) field(f Field) error {
+	if !slices.Contains(p.spec.fields, f.Key) {
+		return errorf(f.Line, "unknown field %q in %s; expected one of %s", f.Key, p.spec.name, strings.Join(p.spec.fields, ", "))
+	}
+	entry := p.entry()
+	if prev, dup := entry.Fields.Lookup(f.Key); dup {
+		return errorf(f.Line, "field %q is already set on line %d", f.Key, prev.Line)
+	}
+	entry.Fields = append(entry.Fields, f)
+	return nil
+}
+
+func (p *parser) item(text string, n int) error {
+	switch {
+	case p.spec == nil:
+		return errorf(n, "list item outside a section")
+	case p.spec.kind == textSection:
+		return errorf(n, "the %s section does not take list items", p.spec.name)
+	case p.spec.kind == entrySection && p.entry() == nil:
+		return errorf(n, "list items in %s must follow a \"###\" entry heading", p.spec.name)
+	case p.spec.kind == entrySection && !p.spec.entryItems:
+		return errorf(n, "%s entries do not take list items", p.spec.name)
+	}
+
+	item := Item{Line: n}
+	item.Text, item.Tags = splitTags(text)
+	if item.Text == "" {
+		return errorf(n, "empty list item")
+	}
+	items := p.items()
+	*items = append(*items, item)
+	p.inItem = true
+	return nil
+}
+
+func (p *parser) textLine(text string, n int) error {
+	switch {
+	case p.doc.NameLine == 0:
+		return errorf(n, "expected \"# Name\" heading before any content")
+	case p.spec == nil:
+		return errorf(n, "text must be inside a \"##\" section")
+	case p.spec.kind == listSection:
+		return errorf(n, "the %s section only takes list items", p.spec.name)
+	case p.spec.kind == entrySection && p.entry() == nil:
+		return errorf(n, "text in %s must follow a \"###\" entry heading", p.spec.name)
+	case p.spec.kind == entrySection && !p.spec.entryText:
+		return errorf(n, "%s entries only take \"key: value\" fields and list items", p.spec.name)
+	}
+	p.text = append(p.text, text)
+	return nil
+}
+
+// flushText stores the text collected since the last heading on the current
+// entry or section, dropping leading and trailing blank lines.
+func (p *parser) flushText() {
+	text := strings.Trim(strings.Join(p.text, "\n"), "\n")
+	p.text = nil
+	if text == "" || p.spec == nil {
+		return
+	}
+	if entry := p.entry(); entry != nil {
+		entry.Text = text
+	} else {
+		p.section().Text = text
+	}
+}
+
+func (p *parser) section() *Section {
+	return &p.doc.Sections[len(p.doc.Sections)-1]
+}
+
+func (p *parser) entry() *Entry {
+	if p.spec == nil || p.spec.kind != entrySection {
+		return nil
+	}
+	section := p.section()
+	if len(section.Entries) == 0 {
+		return nil
+	}
+	return &section.Entries[len(section.Entries)-1]
+}
+
+func (p *parser) items() *[]Item {
+	if entry := p.entry(); entry != nil {
+		return &entry.Items
+	}
+	return &p.section().Items
+}
+
+// splitTags separates a "{tags: ...}" suffix from an item's text. A suffix
+// escaped as "\{tags: ...}" is part of the text and loses its backslash.
+func splitTags(text string) (string, []string) {
+	m := itemTags.FindStringSubmatchIndex(text)
+	if m == nil {
+		return text, nil
+	}
+	if m[3] > m[2] {
+		return unescapeTags(text, m), nil
+	}
+	var tags []string
+	for _, tag := range strings.Split(text[m[4]:m[5]], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	rest := text[:m[0]]
+	if m := itemTags.FindStringSubmatchIndex(rest); m != nil && m[3] > m[2] {
+		rest = unescapeTags(rest, m)
+	}
+	return rest, tags
+}
+
+func unescapeTags(text string, m []int) string {
+	return text[:m[2]] + text[m[3]:]
+}
+
+func parseHeading(line string) (level int, text string, ok bool) {
+	level = len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || (len(line) > level && line[level] != ' ' && line[level] != '\t') {
+		return 0, "", false
+	}
+	return level, strings.TrimSpace(line[level:]), true
+}
+
+func parseField(line string, n int) (Field, bool) {
+	m := fieldLine.FindStringSubmatch(line)
+	if m == nil {
+		return Field{}, false
+	}
+	return Field{Key: m[1], Value: strings.TrimSpace(m[2]), Line: n}, true
+}
+
+func lookupSection(name string) *sectionSpec {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, spec := range sectionSpecs {
+		if strings.ToLower(spec.name) == name || slices.Contains(spec.aliases, name) {
+			return &sectionSpecs[i]
+		}
+	}
+	return nil
+}
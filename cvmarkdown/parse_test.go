@@ -0,0 +1,211 @@
+package cvmarkdown
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sample = `---
+address: 1 High Street, Leeds
+phone1: 07700 900000
+email: jane@example.com
+---
+
+# Jane Doe
+
+## Summary
+
+Engineer who likes
+\- plain text.
+
+## Skills
+
+- Go {tags: backend, cloud}
+- Public speaking
+
+## Experience
+
+### Senior Engineer
+company: Acme Ltd
+start: 2020-01
+end: present
+tags: backend
+
+- Led the move to Kubernetes
+- Wrote the {tags: x} guide \{tags: docs}
+
+## Education
+
+### University of Leeds
+qualification: BSc
+start: 2012
+
+Notes follow the fields.
+
+## Certifications
+
+### Certified Kubernetes Administrator
+issuer: CNCF
+issued: 2023-04
+
+## Interests
+
+- Climbing
+`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if doc.Name != "Jane Doe" || doc.NameLine != 7 {
+		t.Errorf("name = %q on line %d", doc.Name, doc.NameLine)
+	}
+	if got := doc.FrontMatter.Get("phone1"); got != "07700 900000" {
+		t.Errorf("phone1 = %q", got)
+	}
+
+	var names []string
+	for _, section := range doc.Sections {
+		names = append(names, section.Name)
+	}
+	if want := []string{Summary, Skills, Experience, Education, Certifications, Interests}; !reflect.DeepEqual(names, want) {
+		t.Errorf("sections = %q, want %q", names, want)
+	}
+	if got := doc.Section(Summary).Text; got != "Engineer who likes\n- plain text." {
+		t.Errorf("summary = %q", got)
+	}
+
+	role := doc.Section(Experience).Entries[0]
+	if role.Heading != "Senior Engineer" || role.Line != 21 || role.Fields.Get("end") != "present" {
+		t.Errorf("role = %+v", role)
+	}
+	if got := role.Items[1]; got.Text != "Wrote the {tags: x} guide {tags: docs}" || got.Tags != nil || got.Line != 28 {
+		t.Errorf("escaped item = %+v", got)
+	}
+	if got := doc.Section(Education).Entries[0].Text; got != "Notes follow the fields." {
+		t.Errorf("education notes = %q", got)
+	}
+}
+
+func TestItemTags(t *testing.T) {
+	tests := []struct {
+		line string
+		text string
+		tags []string
+	}{
+		{"- Go", "Go", nil},
+		{"- Go {tags: backend}", "Go", []string{"backend"}},
+		{"- Go {tags: backend, cloud }", "Go", []string{"backend", "cloud"}},
+		{"- Go {tags:}", "Go", nil},
+		{"- Go {tags: a} more", "Go {tags: a} more", nil},
+		{`- Go \{tags: a}`, "Go {tags: a}", nil},
+		{`- Go \{tags: a} {tags: b}`, "Go {tags: a}", []string{"b"}},
+		{`- Go \\{tags: a}`, `Go \{tags: a}`, nil},
+	}
+	for _, tt := range tests {
+		doc, err := Parse([]byte("# Name\n\n## Skills\n\n" + tt.line + "\n"))
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.line, err)
+		}
+		item := doc.Section(Skills).Items[0]
+		if item.Text != tt.text || !reflect.DeepEqual(item.Tags, tt.tags) {
+			t.Errorf("%q parsed as %q %q, want %q %q", tt.line, item.Text, item.Tags, tt.text, tt.tags)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+		msg   string
+	}{
+		{"missing name", "## Skills\n", 1, "expected \"# Name\" heading"},
+		{"empty document", "", 1, "missing \"# Name\" heading"},
+		{"second name", "# A\n# B\n", 2, "the name is already set on line 1"},
+		{"empty name", "#\n", 1, "the name is empty"},
+		{"heading without space", "#Name\n", 1, "expected \"# Name\" heading"},
+		{"unknown section", "# A\n\n## Hobbies\n", 3, "unknown section \"Hobbies\""},
+		{"duplicate section", "# A\n## Skills\n## skills\n", 3, "already defined on line 2"},
+		{"entry outside entry section", "# A\n## Skills\n### Go\n", 3, "\"###\" entries are only allowed"},
+		{"entry before section", "# A\n### Go\n", 2, "\"###\" entries are only allowed"},
+		{"empty entry heading", "# A\n## Experience\n###\n", 3, "the entry heading is empty"},
+		{"deep heading", "# A\n## Experience\n#### Note\n", 3, "deeper than \"###\""},
+		{"name after section", "# A\n## Skills\n# B\n", 3, "already set on line 1"},
+		{"unknown field", "# A\n## Experience\n### Dev\nsalary: 1\n", 4, "unknown field \"salary\""},
+		{"duplicate field", "# A\n## Experience\n### Dev\nstart: 2020\nstart: 2021\n", 5, "already set on line 4"},
+		{"text in list section", "# A\n## Skills\nGo\n", 3, "only takes list items"},
+		{"empty item", "# A\n## Skills\n- {tags: x}\n", 3, "empty list item"},
+		{"unclosed front matter", "---\nemail: a@b.c\n# A\n", 1, "not closed"},
+		{"unknown front matter", "---\nname: A\n---\n# A\n", 2, "unknown front matter field \"name\""},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.input))
+		var parseErr *Error
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: error = %v, want *Error", tt.name, err)
+			continue
+		}
+		if parseErr.Line != tt.line || !strings.Contains(parseErr.Message, tt.msg) {
+			t.Errorf("%s: error = %v, want line %d containing %q", tt.name, err, tt.line, tt.msg)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	doc := &Document{
+		Name:        "Jane Doe",
+		FrontMatter: Fields{{Key: "email", Value: "jane@example.com"}, {Key: "phone2", Value: ""}},
+		Sections: []Section{
+			{Name: Summary, Text: "# Not a heading\n- not an item\n\\ leading backslash"},
+			{Name: Skills, Items: []Item{
+				{Text: "Go", Tags: []string{"backend", "cloud"}},
+				{Text: "Literal {tags: kept}"},
+				{Text: "Both {tags: kept}", Tags: []string{"real"}},
+				{Text: `Backslash \{tags: kept}`},
+			}},
+			{Name: Experience, Entries: []Entry{
+				{Heading: "Engineer", Fields: Fields{{Key: "company", Value: "Acme"}}, Items: []Item{{Text: "Shipped"}}},
+			}},
+			{Name: Education, Entries: []Entry{
+				{Heading: "Leeds", Text: "start: looks like a field"},
+			}},
+		},
+	}
+
+	parsed, err := Parse(doc.Bytes())
+	if err != nil {
+		t.Fatalf("Parse: %v\n%s", err, doc.Bytes())
+	}
+	if string(parsed.Bytes()) != string(doc.Bytes()) {
+		t.Errorf("output changed on round trip:\n%s\n---\n%s", doc.Bytes(), parsed.Bytes())
+	}
+
+	if parsed.Name != doc.Name || parsed.FrontMatter.Get("email") != "jane@example.com" {
+		t.Errorf("header = %q %+v", parsed.Name, parsed.FrontMatter)
+	}
+	if _, ok := parsed.FrontMatter.Lookup("phone2"); ok {
+		t.Errorf("empty phone2 was written")
+	}
+	for i, section := range doc.Sections {
+		got := parsed.Sections[i]
+		if got.Name != section.Name || got.Text != section.Text {
+			t.Errorf("section %d = %q %q, want %q %q", i, got.Name, got.Text, section.Name, section.Text)
+		}
+		for j, item := range section.Items {
+			if got.Items[j].Text != item.Text || !reflect.DeepEqual(got.Items[j].Tags, item.Tags) {
+				t.Errorf("item %q %q came back as %q %q", item.Text, item.Tags, got.Items[j].Text, got.Items[j].Tags)
+			}
+		}
+		for j, entry := range section.Entries {
+			e := got.Entries[j]
+			if e.Heading != entry.Heading || e.Text != entry.Text || len(e.Fields) != len(entry.Fields) {
+				t.Errorf("entry %+v came back as %+v", entry, e)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package cvmarkdown
+
+import "strings"
+
+// Bytes writes the document in the package convention. Fields with empty
+// values are left out, and headings, field values and list items are
+// written on a single line.
+func (d *Document) Bytes() []byte {
+	var b strings.Builder
+	if fields := nonEmpty(d.FrontMatter); len(fields) > 0 {
+		b.WriteString("---\n")
+		writeFields(&b, fields)
+		b.WriteString("---\n\n")
+	}
+	b.WriteString("# " + oneLine(d.Name) + "\n")
+
+	for _, section := range d.Sections {
+		b.WriteString("\n## " + section.Name + "\n")
+		if section.Text != "" {
+			b.WriteString("\n" + escapeText(section.Text, false) + "\n")
+		}
+		writeItems(&b, section.Items)
+		for _, entry := range section.Entries {
+			b.WriteString("\n### " + oneLine(entry.Heading) + "\n")
+			fields := nonEmpty(entry.Fields)
+			writeFields(&b, fields)
+			if entry.Text != "" {
+				b.WriteString("\n" + escapeText(entry.Text, len(fields) == 0) + "\n")
+			}
+			writeItems(&b, entry.Items)
+		}
+	}
+	return []byte(b.String())
+}
+
+func writeFields(b *strings.Builder, fields Fields) {
+	for _, f := range fields {
+		b.WriteString(f.Key + ": " + oneLine(f.Value) + "\n")
+	}
+}
+
+func writeItems(b *strings.Builder, items []Item) {
+	if len(items) == 0 {
+		return
+	}
+	b.WriteString("\n")
+	for _, item := range items {
+		b.WriteString("- " + escapeTags(oneLine(item.Text)))
+		if len(item.Tags) > 0 {
+			b.WriteString(" {tags: " + strings.Join(item.Tags, ", ") + "}")
+		}
+		b.WriteString("\n")
+	}
+}
+
+// escapeTags adds a backslash before a trailing "{tags: ...}" that is part
+// of an item's text, so Parse does not read it as the item's tags.
+func escapeTags(text string) string {
+	if m := itemTags.FindStringSubmatchIndex(text); m != nil {
+		return text[:m[2]] + `\` + text[m[2]:]
+	}
+	return text
+}
+
+func nonEmpty(fields Fields) Fields {
+	var kept Fields
+	for _, f := range fields {
+		if strings.TrimSpace(f.Value) != "" {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// escapeText backslash-escapes lines that Parse would otherwise read as
+// headings or list items. When the text directly follows an entry heading,
+// a first line that looks like a field is escaped too.
+func escapeText(text string, afterHeading bool) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		_, _, heading := parseHeading(line)
+		_, field := parseField(line, 0)
+		if heading || listMarker.MatchString(line) || strings.HasPrefix(line, `\`) || (i == 0 && afterHeading && field) {
+			line = `\` + line
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func listCVs(c *gin.Context) {
+	summaries, err := store.List(c.Request.Context(), currentUser(c).ID)
+	if err != nil {
+		log.Printf("Error listing CVs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list CVs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cvs": summaries})
+}
+
+func createCV(c *gin.Context) {
+	cvData, ok := bindCVData(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	doc := cvDocument{ID: uuid.New().String(), OwnerID: currentUser(c).ID, Data: cvData, CreatedAt: now, UpdatedAt: now}
+	if err := store.Create(c.Request.Context(), &doc, requestAuthor(c)); err != nil {
+		log.Printf("Error saving CV: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save CV"})
+		return
+	}
+
+	c.Header("Location", "/api/cvs/"+doc.ID)
+	c.JSON(http.StatusCreated, doc)
+}
+
+func getCV(c *gin.Context) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+func updateCV(c *gin.Context) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return
+	}
+	cvData, ok := bindCVData(c)
+	if !ok {
+		return
+	}
+
+	doc.Data = cvData
+	doc.UpdatedAt = time.Now().UTC()
+	if err := store.Update(c.Request.Context(), &doc, requestAuthor(c)); err != nil {
+		respondStoreError(c, err, "Error updating CV", "Failed to save CV")
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+func deleteCV(c *gin.Context) {
+	if err := store.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		respondStoreError(c, err, "Error deleting CV", "Failed to delete CV")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// generateStoredPDF renders a saved CV, accepting the same query parameters
+// and Accept header as generate-pdf.
+func generateStoredPDF(c *gin.Context) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return
+	}
+	respondWithPDF(c, doc.Data)
+}
+
+// requestAuthor names who is saving a revision.
+func requestAuthor(c *gin.Context) string {
+	return currentUser(c).displayName()
+}
+
+// requireCVOwner guards every route under /cvs/:id. CVs belonging to someone
+// else get the same 404 as missing ones, so users cannot probe for them.
+func requireCVOwner(c *gin.Context) {
+	owner, err := store.CVOwner(c.Request.Context(), c.Param("id"))
+	if err == nil && owner != currentUser(c).ID {
+		err = errNotFound
+	}
+	if err != nil {
+		respondStoreError(c, err, "Error loading CV", "Failed to load CV")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+func loadCV(c *gin.Context) (cvDocument, bool) {
+	doc, err := store.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondStoreError(c, err, "Error loading CV", "Failed to load CV")
+		return cvDocument{}, false
+	}
+	return doc, true
+}
+
+// respondStoreError answers 404 for errNotFound and logs anything else as an
+// internal error.
+func respondStoreError(c *gin.Context, err error, logPrefix, message string) {
+	if errors.Is(err, errNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "CV not found"})
+		return
+	}
+	log.Printf("%s: %v", logPrefix, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
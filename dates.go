@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// YearMonth is a month-precision date used for experience ranges. A zero
+// Month means only the year is known, and Present marks an ongoing role.
+type YearMonth struct {
+	Year    int
+	Month   time.Month
+	Present bool
+}
+
+func (ym YearMonth) MarshalText() ([]byte, error) {
+	switch {
+	case ym.Present:
+		return []byte("present"), nil
+	case ym.Month == 0:
+		return []byte(fmt.Sprintf("%04d", ym.Year)), nil
+	default:
+		return []byte(fmt.Sprintf("%04d-%02d", ym.Year, ym.Month)), nil
+	}
+}
+
+func (ym *YearMonth) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	if isPresentWord(value) {
+		*ym = YearMonth{Present: true}
+		return nil
+	}
+	if t, err := time.Parse("2006-01", value); err == nil {
+		*ym = YearMonth{Year: t.Year(), Month: t.Month()}
+		return nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		*ym = YearMonth{Year: t.Year(), Month: t.Month()}
+		return nil
+	}
+	if t, err := time.Parse("2006", value); err == nil {
+		*ym = YearMonth{Year: t.Year()}
+		return nil
+	}
+	return fmt.Errorf("invalid date %q: expected YYYY-MM, YYYY or \"present\"", value)
+}
+
+// Format renders the date with a Go time layout, dropping the month part of
+// the layout when only the year is known.
+func (ym YearMonth) Format(layout string) string {
+	if ym.Present {
+		return "Present"
+	}
+	if ym.Month == 0 {
+		return strconv.Itoa(ym.Year)
+	}
+	return time.Date(ym.Year, ym.Month, 1, 0, 0, 0, 0, time.UTC).Format(layout)
+}
+
+// monthIndex returns a sortable month number. Year-only dates resolve to the
+// first month when used as a start and the last month when used as an end.
+func (ym YearMonth) monthIndex(isEnd bool, now time.Time) int {
+	if ym.Present {
+		return now.Year()*12 + int(now.Month()) - 1
+	}
+	month := ym.Month
+	if month == 0 {
+		month = time.January
+		if isEnd {
+			month = time.December
+		}
+	}
+	return ym.Year*12 + int(month) - 1
+}
+
+// formatDate re-formats a free-text date with layout when it parses as a
+// YearMonth, returning it unchanged otherwise.
+func formatDate(value, layout string) string {
+	var ym YearMonth
+	if err := ym.UnmarshalText([]byte(value)); err != nil {
+		return value
+	}
+	return ym.Format(layout)
+}
+
+func isPresentWord(value string) bool {
+	switch strings.ToLower(value) {
+	case "present", "current", "now", "today", "date", "ongoing":
+		return true
+	}
+	return false
+}
+
+var monthNames = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "sept": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+var periodToken = regexp.MustCompile(`(?i)(\d{4})-(0[1-9]|1[0-2])\b|(\d{1,2})/(\d{4})|([a-z]{3,9})\.?,?\s+(\d{4})|(\d{4})|\b(present|current|now|today|date|ongoing)\b`)
+
+// parsePeriod makes a best-effort attempt to read a legacy free-text period
+// such as "Jan 2019 - Present", "03/2015 – 11/2018" or "2012 to 2014". A
+// period with only a start, such as "Since 2019" or "Jan 2019 -", is ongoing
+// and has a nil end. Any other single date, such as "2019" or "Summer 2019
+// (contract)", starts and ends together.
+func parsePeriod(period string) (start, end *YearMonth, ok bool) {
+	var dates []YearMonth
+	var before, after string
+	for _, loc := range periodToken.FindAllStringSubmatchIndex(period, -1) {
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			if loc[2*i] >= 0 {
+				m[i] = period[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		var ym YearMonth
+		from, to := loc[0], loc[1]
+		switch {
+		case m[1] != "":
+			ym.Year, _ = strconv.Atoi(m[1])
+			month, _ := strconv.Atoi(m[2])
+			ym.Month = time.Month(month)
+		case m[3] != "":
+			month, _ := strconv.Atoi(m[3])
+			ym.Month = time.Month(month)
+			ym.Year, _ = strconv.Atoi(m[4])
+		case m[5] != "":
+			name := strings.ToLower(m[5])
+			month, known := monthNames[name]
+			if !known && len(name) > 3 {
+				month, known = monthNames[name[:3]]
+			}
+			if known {
+				ym.Month = month
+			} else {
+				// A word such as "since" is not part of the date.
+				from = loc[12]
+			}
+			ym.Year, _ = strconv.Atoi(m[6])
+		case m[7] != "":
+			ym.Year, _ = strconv.Atoi(m[7])
+		default:
+			ym.Present = true
+		}
+		if ym.Month > 12 {
+			continue
+		}
+		dates = append(dates, ym)
+		before, after = period[:from], period[to:]
+	}
+
+	switch {
+	case len(dates) == 0 || dates[0].Present:
+		return nil, nil, false
+	case len(dates) == 1:
+		if openEnded(before, after) {
+			return &dates[0], nil, true
+		}
+		return &dates[0], &dates[0], true
+	default:
+		return &dates[0], &dates[1], true
+	}
+}
+
+// openEnded reports whether the text around a period's only date marks it
+// as a start, as in "Since 2019", "From 2019" or "2019 -".
+func openEnded(before, after string) bool {
+	const space = " \t()[].,"
+	before = strings.ToLower(strings.Trim(before, space))
+	after = strings.Trim(after, space)
+	switch {
+	case before == "" && after != "":
+		return strings.Trim(after, "-–— \t") == ""
+	case after == "":
+		return before == "since" || before == "from"
+	}
+	return false
+}
+
+// resolvePeriod fills in the structured dates of an experience entry from its
+// legacy period string when none were supplied.
+func (e *Experience) resolvePeriod() {
+	if e.Start != nil || e.Period == "" {
+		return
+	}
+	if start, end, ok := parsePeriod(e.Period); ok {
+		e.Start, e.End = start, end
+	}
+}
+
+// DateRange renders the role's dates with layout, falling back to the legacy
+// period string when there are no structured dates.
+func (e Experience) DateRange(layout string) string {
+	if e.Start == nil {
+		return e.Period
+	}
+	start := e.Start.Format(layout)
+	if e.End == nil || e.End.Present {
+		return start + " - Present"
+	}
+	end := e.End.Format(layout)
+	if end == start {
+		return start
+	}
+	return start + " - " + end
+}
+
+func (e Experience) span(now time.Time) (from, to int, ok bool) {
+	if e.Start == nil {
+		return 0, 0, false
+	}
+	from = e.Start.monthIndex(false, now)
+	to = YearMonth{Present: true}.monthIndex(true, now)
+	if e.End != nil {
+		to = e.End.monthIndex(true, now)
+	}
+	return from, to, to >= from
+}
+
+// sortExperience orders roles most recent first: ongoing roles lead, then by
+// end date and start date. Roles without structured dates keep their
+// relative order at the end.
+func sortExperience(experience []Experience, now time.Time) {
+	sort.SliceStable(experience, func(i, j int) bool {
+		fi, ti, oki := experience[i].span(now)
+		fj, tj, okj := experience[j].span(now)
+		if oki != okj {
+			return oki
+		}
+		if ti != tj {
+			return ti > tj
+		}
+		return fi > fj
+	})
+}
+
+// totalExperienceMonths returns the number of months covered by the
+// experience entries, counting overlapping roles only once.
+func totalExperienceMonths(experience []Experience, now time.Time) int {
+	type interval struct{ from, to int }
+	var intervals []interval
+	for _, exp := range experience {
+		exp.resolvePeriod()
+		if from, to, ok := exp.span(now); ok {
+			intervals = append(intervals, interval{from, to})
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].from < intervals[j].from })
+
+	total, coveredTo := 0, -1
+	for _, iv := range intervals {
+		if iv.from <= coveredTo {
+			iv.from = coveredTo + 1
+		}
+		if iv.to >= iv.from {
+			total += iv.to - iv.from + 1
+			coveredTo = iv.to
+		}
+	}
+	return total
+}
+
+func experienceSummary(experience []Experience, opts renderOptions) string {
+	if !opts.ShowExperienceYears {
+		return ""
+	}
+	switch years := totalExperienceMonths(experience, opts.Now) / 12; years {
+	case 0:
+		return ""
+	case 1:
+		return "1 year of professional experience"
+	default:
+		return fmt.Sprintf("%d years of professional experience", years)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func ym(year int, month time.Month) *YearMonth {
+	return &YearMonth{Year: year, Month: month}
+}
+
+func TestParsePeriod(t *testing.T) {
+	present := &YearMonth{Present: true}
+	tests := []struct {
+		period     string
+		start, end *YearMonth
+		ok         bool
+	}{
+		{"Jan 2019 - Present", ym(2019, time.January), present, true},
+		{"January 2019 – December 2020", ym(2019, time.January), ym(2020, time.December), true},
+		{"Sept. 2016 to Mar 2018", ym(2016, time.September), ym(2018, time.March), true},
+		{"03/2015 – 11/2018", ym(2015, time.March), ym(2018, time.November), true},
+		{"2015-03 - 2018-11", ym(2015, time.March), ym(2018, time.November), true},
+		{"2012 to 2014", ym(2012, 0), ym(2014, 0), true},
+		{"2020 - current", ym(2020, 0), present, true},
+		{"Since 2019", ym(2019, 0), nil, true},
+		{"since Mar 2019", ym(2019, time.March), nil, true},
+		{"Jan 2019 -", ym(2019, time.January), nil, true},
+		{"2019 – ", ym(2019, 0), nil, true},
+		{"From 2021", ym(2021, 0), nil, true},
+		{"2019", ym(2019, 0), ym(2019, 0), true},
+		{"(Jun 2019)", ym(2019, time.June), ym(2019, time.June), true},
+		{"2019 (contract)", ym(2019, 0), ym(2019, 0), true},
+		{"2019 (6 months)", ym(2019, 0), ym(2019, 0), true},
+		{"Summer 2019", ym(2019, 0), ym(2019, 0), true},
+		{"Mandate 2019", ym(2019, 0), ym(2019, 0), true},
+		{"Update 2019 -", ym(2019, 0), ym(2019, 0), true},
+		{"2019, job update", ym(2019, 0), ym(2019, 0), true},
+		{"2019 - 2021 (contract)", ym(2019, 0), ym(2021, 0), true},
+		{"13/2019", nil, nil, false},
+		{"", nil, nil, false},
+		{"a few years", nil, nil, false},
+		{"Present", nil, nil, false},
+	}
+	for _, tt := range tests {
+		start, end, ok := parsePeriod(tt.period)
+		if ok != tt.ok || !sameYearMonth(start, tt.start) || !sameYearMonth(end, tt.end) {
+			t.Errorf("parsePeriod(%q) = %v, %v, %v; want %v, %v, %v",
+				tt.period, start, end, ok, tt.start, tt.end, tt.ok)
+		}
+	}
+}
+
+func sameYearMonth(a, b *YearMonth) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func TestTotalExperienceMonths(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		periods []string
+		want    int
+	}{
+		{"closed range", []string{"Jan 2020 - Dec 2020"}, 12},
+		{"ongoing", []string{"Jan 2024 - Present"}, 6},
+		{"start only", []string{"Since Jan 2024"}, 6},
+		{"overlap counted once", []string{"Jan 2020 - Dec 2021", "Jun 2021 - Jun 2022"}, 30},
+		{"year only", []string{"2019"}, 12},
+		{"unparseable", []string{"a while"}, 0},
+	}
+	for _, tt := range tests {
+		var experience []Experience
+		for _, period := range tt.periods {
+			experience = append(experience, Experience{Period: period})
+		}
+		if got := totalExperienceMonths(experience, now); got != tt.want {
+			t.Errorf("%s: totalExperienceMonths = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSortExperienceOngoingFirst(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+	experience := []Experience{
+		{Title: "old", Period: "2010 - 2012"},
+		{Title: "undated", Period: "a while"},
+		{Title: "current", Period: "Since 2015"},
+		{Title: "recent", Period: "2016 - 2023"},
+	}
+	for i := range experience {
+		experience[i].resolvePeriod()
+	}
+	sortExperience(experience, now)
+
+	want := []string{"current", "recent", "old", "undated"}
+	for i, exp := range experience {
+		if exp.Title != want[i] {
+			t.Fatalf("position %d = %q, want %q", i, exp.Title, want[i])
+		}
+	}
+}
+
+func TestYearMonthText(t *testing.T) {
+	for _, text := range []string{"2019-03", "2019", "present"} {
+		var value YearMonth
+		if err := value.UnmarshalText([]byte(text)); err != nil {
+			t.Fatalf("UnmarshalText(%q): %v", text, err)
+		}
+		out, _ := value.MarshalText()
+		if string(out) != text {
+			t.Errorf("round trip of %q gave %q", text, out)
+		}
+	}
+	var value YearMonth
+	if err := value.UnmarshalText([]byte("March 2019")); err == nil {
+		t.Errorf("UnmarshalText accepted free text")
+	}
+}
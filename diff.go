@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// cvDiff describes what changed between two versions of a CV. Empty parts
+// are omitted so an unchanged document diffs to just the revision numbers.
+type cvDiff struct {
+	From           int             `json:"from"`
+	To             int             `json:"to"`
+	Fields         []fieldChange   `json:"fields,omitempty"`
+	Skills         *listDiff       `json:"skills,omitempty"`
+	Experience     *experienceDiff `json:"experience,omitempty"`
+	Education      *listDiff       `json:"education,omitempty"`
+	Certifications *listDiff       `json:"certifications,omitempty"`
+	Interests      *listDiff       `json:"interests,omitempty"`
+}
+
+type fieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// listDiff compares two lists of items. For lists of records, items are
+// identified by a label and Changed names records whose content differs.
+type listDiff struct {
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+	Changed   []string `json:"changed,omitempty"`
+	Reordered bool     `json:"reordered,omitempty"`
+}
+
+type experienceDiff struct {
+	Added     []string           `json:"added,omitempty"`
+	Removed   []string           `json:"removed,omitempty"`
+	Changed   []experienceChange `json:"changed,omitempty"`
+	Reordered bool               `json:"reordered,omitempty"`
+}
+
+type experienceChange struct {
+	Role   string        `json:"role"`
+	Fields []fieldChange `json:"fields,omitempty"`
+	Duties *listDiff     `json:"duties,omitempty"`
+}
+
+func diffCVData(from, to CVData) cvDiff {
+	var diff cvDiff
+	diff.Fields = diffFields([][3]string{
+		{"name", from.Name, to.Name},
+		{"address", from.Address, to.Address},
+		{"phone1", from.Phone1, to.Phone1},
+		{"phone2", from.Phone2, to.Phone2},
+		{"email", from.Email, to.Email},
+		{"statement", from.Statement, to.Statement},
+	})
+	diff.Skills = diffRecords(from.Skills, to.Skills, textOf)
+	diff.Interests = diffStrings(from.Interests, to.Interests)
+	diff.Experience = diffExperience(from.Experience, to.Experience)
+	diff.Education = diffRecords(from.Education, to.Education, func(e Education) string {
+		return joinNonEmpty(", ", joinNonEmpty(" in ", e.Qualification, e.Field), e.Institution)
+	})
+	diff.Certifications = diffRecords(from.Certifications, to.Certifications, func(c Certification) string {
+		return joinNonEmpty(", ", c.Name, c.Issuer)
+	})
+	return diff
+}
+
+func diffFields(fields [][3]string) []fieldChange {
+	var changes []fieldChange
+	for _, f := range fields {
+		if f[1] != f[2] {
+			changes = append(changes, fieldChange{Field: f[0], From: f[1], To: f[2]})
+		}
+	}
+	return changes
+}
+
+// diffStrings compares two string lists as multisets, additionally reporting
+// whether the items present in both appear in a different order.
+func diffStrings(from, to []string) *listDiff {
+	fromKeys, toKeys := occurrenceKeys(from, identity), occurrenceKeys(to, identity)
+	diff := &listDiff{}
+	for i, key := range fromKeys {
+		if !containsKey(toKeys, key) {
+			diff.Removed = append(diff.Removed, from[i])
+		}
+	}
+	for i, key := range toKeys {
+		if !containsKey(fromKeys, key) {
+			diff.Added = append(diff.Added, to[i])
+		}
+	}
+	diff.Reordered = reordered(fromKeys, toKeys)
+	return nilIfEmpty(diff)
+}
+
+// diffRecords matches records by label and reports which were added,
+// removed, edited or moved.
+func diffRecords[T any](from, to []T, label func(T) string) *listDiff {
+	fromKeys, toKeys := occurrenceKeys(from, label), occurrenceKeys(to, label)
+	diff := &listDiff{}
+	for i, key := range fromKeys {
+		j := indexOfKey(toKeys, key)
+		switch {
+		case j < 0:
+			diff.Removed = append(diff.Removed, label(from[i]))
+		case !reflect.DeepEqual(from[i], to[j]):
+			diff.Changed = append(diff.Changed, label(from[i]))
+		}
+	}
+	for i, key := range toKeys {
+		if !containsKey(fromKeys, key) {
+			diff.Added = append(diff.Added, label(to[i]))
+		}
+	}
+	diff.Reordered = reordered(fromKeys, toKeys)
+	return nilIfEmpty(diff)
+}
+
+// diffExperience matches roles by title and company, so edits to a role's
+// dates or duties show up as changes rather than a removal and an addition.
+func diffExperience(from, to []Experience) *experienceDiff {
+	fromKeys, toKeys := occurrenceKeys(from, roleLabel), occurrenceKeys(to, roleLabel)
+	diff := &experienceDiff{}
+	for i, key := range fromKeys {
+		j := indexOfKey(toKeys, key)
+		if j < 0 {
+			diff.Removed = append(diff.Removed, roleLabel(from[i]))
+			continue
+		}
+		a, b := from[i], to[j]
+		change := experienceChange{
+			Role: roleLabel(a),
+			Fields: diffFields([][3]string{
+				{"dates", a.DateRange(dateFormats["iso"]), b.DateRange(dateFormats["iso"])},
+				{"tags", strings.Join(a.Tags, ", "), strings.Join(b.Tags, ", ")},
+			}),
+			Duties: diffRecords(a.Duties, b.Duties, textOf),
+		}
+		if change.Fields != nil || change.Duties != nil {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for i, key := range toKeys {
+		if !containsKey(fromKeys, key) {
+			diff.Added = append(diff.Added, roleLabel(to[i]))
+		}
+	}
+	diff.Reordered = reordered(fromKeys, toKeys)
+	if diff.Added == nil && diff.Removed == nil && diff.Changed == nil && !diff.Reordered {
+		return nil
+	}
+	return diff
+}
+
+func roleLabel(e Experience) string {
+	return joinNonEmpty(" at ", e.Title, e.Company)
+}
+
+func identity(s string) string {
+	return s
+}
+
+// occurrenceKeys labels each item, numbering repeats so that the nth copy of
+// an item in one list matches the nth copy in the other.
+func occurrenceKeys[T any](items []T, label func(T) string) []string {
+	seen := make(map[string]int, len(items))
+	keys := make([]string, len(items))
+	for i, item := range items {
+		l := strings.TrimSpace(label(item))
+		keys[i] = fmt.Sprintf("%s#%d", l, seen[l])
+		seen[l]++
+	}
+	return keys
+}
+
+func indexOfKey(keys []string, key string) int {
+	for i, k := range keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsKey(keys []string, key string) bool {
+	return indexOfKey(keys, key) >= 0
+}
+
+// reordered reports whether the keys common to both lists appear in a
+// different relative order.
+func reordered(from, to []string) bool {
+	var a, b []string
+	for _, k := range from {
+		if containsKey(to, k) {
+			a = append(a, k)
+		}
+	}
+	for _, k := range to {
+		if containsKey(from, k) {
+			b = append(b, k)
+		}
+	}
+	return !reflect.DeepEqual(a, b)
+}
+
+func nilIfEmpty(diff *listDiff) *listDiff {
+	if diff.Added == nil && diff.Removed == nil && diff.Changed == nil && !diff.Reordered {
+		return nil
+	}
+	return diff
+}
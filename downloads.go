@@ -0,0 +1,81 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// downloadFormat describes a generated file offered through the temp-file
+// download-link flow: it is saved in the user's directory under tempDir and
+// served from /download-<route>/:filename as an attachment called
+// "cv.<extension>".
+type downloadFormat struct {
+	route     string
+	extension string
+	label     string
+}
+
+func (f downloadFormat) path() string {
+	return "/download-" + f.route + "/:filename"
+}
+
+// saveDownload stores data for later download and responds with its link.
+func saveDownload(c *gin.Context, format downloadFormat, data []byte) {
+	filename := "cv_" + uuid.New().String() + "." + format.extension
+	path, err := newDownloadPath(c, filename)
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		log.Printf("Error saving %s: %v", format.label, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save " + format.label})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"download_link": "/download-" + format.route + "/" + filename,
+	})
+}
+
+// serveDownload returns the handler for format's download route. Only files
+// with the format's extension are served.
+func serveDownload(format downloadFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filename := filepath.Base(c.Param("filename"))
+		path := downloadPath(c, filename)
+
+		if !strings.HasSuffix(filename, "."+format.extension) {
+			c.JSON(http.StatusNotFound, gin.H{"error": format.label + " not found"})
+			return
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": format.label + " not found"})
+			return
+		}
+
+		c.FileAttachment(path, "cv."+format.extension)
+	}
+}
+
+// newDownloadPath returns where to save a generated file for the signed-in
+// user, creating their directory if needed. Each user has their own
+// directory under tempDir, so download links only work for whoever
+// generated the file.
+func newDownloadPath(c *gin.Context, filename string) (string, error) {
+	dir := filepath.Join(tempDir, currentUser(c).ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filename), nil
+}
+
+// downloadPath is where the signed-in user's generated file would be.
+func downloadPath(c *gin.Context, filename string) string {
+	return filepath.Join(tempDir, currentUser(c).ID, filepath.Base(filename))
+}
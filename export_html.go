@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var htmlDownload = downloadFormat{route: "html", extension: "html", label: "HTML file"}
+
+// exportHTML offers the rendered template as a single HTML file for hosting
+// or emailing. Pass stylesheet=screen to add a layout for reading on screen;
+// the template's print styles still apply when the page is printed.
+func exportHTML(c *gin.Context) {
+	var cvData CVData
+	if !bindCV(c, &cvData) {
+		return
+	}
+
+	page, err := renderCVHTML(c.Request.Context(), cvData, parseRenderOptions(c))
+	if errors.Is(err, errUnknownTemplate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown template"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate HTML"})
+		return
+	}
+
+	data, err := standaloneHTML(page.Bytes(), c.Query("stylesheet") == "screen")
+	if err != nil {
+		log.Printf("Error generating standalone HTML: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate HTML"})
+		return
+	}
+	saveDownload(c, htmlDownload, data)
+}
+
+// standaloneHTML checks that a rendered page loads nothing from elsewhere,
+// embeds the export font and optionally adds the screen stylesheet.
+// Templates already inline their CSS and images, so the check guards
+// against a template change quietly adding an external reference.
+func standaloneHTML(page []byte, screen bool) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSelfContained(doc); err != nil {
+		return nil, err
+	}
+
+	if err := addEmbeddedFont(doc); err != nil {
+		return nil, err
+	}
+	if screen {
+		if err := addScreenStylesheet(doc); err != nil {
+			return nil, err
+		}
+	}
+	return renderHTML(doc)
+}
+
+// exportFontFaces are the Open Sans files embedded in standalone HTML, one
+// for each weight and style the templates use.
+var exportFontFaces = []struct {
+	file   string
+	weight string
+	style  string
+}{
+	{"open-sans-light.woff2", "300", "normal"},
+	{"open-sans-regular.woff2", "400", "normal"},
+	{"open-sans-italic.woff2", "400", "italic"},
+	{"open-sans-bold.woff2", "700", "normal"},
+}
+
+// addEmbeddedFont makes the page use Open Sans, embedded as data URIs. The
+// templates name system fonts, which differ between machines, so without it
+// a downloaded page would not look the same everywhere.
+func addEmbeddedFont(doc *html.Node) error {
+	var css strings.Builder
+	for _, face := range exportFontFaces {
+		data, err := assetFS.ReadFile("assets/fonts/" + face.file)
+		if err != nil {
+			return fmt.Errorf("reading font: %w", err)
+		}
+		fmt.Fprintf(&css, "@font-face{font-family:'CV Builder Sans';font-weight:%s;font-style:%s;src:url(data:font/woff2;base64,%s) format('woff2');}\n",
+			face.weight, face.style, base64.StdEncoding.EncodeToString(data))
+	}
+	css.WriteString("body{font-family:'CV Builder Sans',Arial,sans-serif;}\n")
+	return appendStyle(doc, css.String(), "")
+}
+
+// addScreenStylesheet appends the screen stylesheet to the page's head.
+func addScreenStylesheet(doc *html.Node) error {
+	css, err := assetFS.ReadFile("assets/css/screen.css")
+	if err != nil {
+		return fmt.Errorf("reading screen stylesheet: %w", err)
+	}
+	return appendStyle(doc, string(css), "screen")
+}
+
+// appendStyle adds a style element at the end of the page's head, so its
+// rules take precedence over the template's.
+func appendStyle(doc *html.Node, css, media string) error {
+	head := findElement(doc, atom.Head)
+	if head == nil {
+		return errors.New("page has no head")
+	}
+	style := &html.Node{Type: html.ElementNode, Data: "style", DataAtom: atom.Style}
+	if media != "" {
+		style.Attr = []html.Attribute{{Key: "media", Val: media}}
+	}
+	style.AppendChild(&html.Node{Type: html.TextNode, Data: css})
+	head.AppendChild(style)
+	return nil
+}
+
+func renderHTML(doc *html.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resourceAttrs are the attributes that make a browser fetch something.
+// Link targets such as <a href> are left alone.
+var resourceAttrs = map[string]bool{"src": true, "srcset": true, "poster": true, "data": true, "background": true}
+
+var cssURL = regexp.MustCompile(`url\(\s*['"]?([^'")\s]+)|@import\s+['"]([^'"]+)`)
+
+func checkSelfContained(n *html.Node) error {
+	if n.Type == html.ElementNode {
+		for _, attr := range n.Attr {
+			external := resourceAttrs[attr.Key] || (n.DataAtom == atom.Link && attr.Key == "href")
+			if external && !isInlineURL(attr.Val) {
+				return fmt.Errorf("<%s %s> loads %q", n.Data, attr.Key, attr.Val)
+			}
+			if attr.Key == "style" {
+				if err := checkCSS(attr.Val); err != nil {
+					return err
+				}
+			}
+		}
+		if n.DataAtom == atom.Style && n.FirstChild != nil {
+			if err := checkCSS(n.FirstChild.Data); err != nil {
+				return err
+			}
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if err := checkSelfContained(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkCSS(css string) error {
+	for _, m := range cssURL.FindAllStringSubmatch(css, -1) {
+		if u := m[1] + m[2]; !isInlineURL(u) {
+			return fmt.Errorf("stylesheet loads %q", u)
+		}
+	}
+	return nil
+}
+
+func isInlineURL(u string) bool {
+	u = strings.TrimSpace(u)
+	return u == "" || strings.HasPrefix(u, "data:") || strings.HasPrefix(u, "#")
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -6,13 +6,11 @@ import (
 	"github.com/google/uuid"
 	"net/http"
 	"os"
-	"path/filepath"
 )
 
 func exportJSON(c *gin.Context) {
 	var cvData CVData
-	if err := c.BindJSON(&cvData); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid JSON data"})
+	if !bindCV(c, &cvData) {
 		return
 	}
 
@@ -23,9 +21,11 @@ func exportJSON(c *gin.Context) {
 	}
 
 	filename := "cv_data_" + uuid.New().String() + ".json"
-	path := filepath.Join(tempDir, filename)
-
-	if err := os.WriteFile(path, jsonData, 0644); err != nil {
+	path, err := newDownloadPath(c, filename)
+	if err == nil {
+		err = os.WriteFile(path, jsonData, 0644)
+	}
+	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to save JSON file"})
 		return
 	}
@@ -38,8 +38,7 @@ func exportJSON(c *gin.Context) {
 }
 
 func downloadJSON(c *gin.Context) {
-	filename := c.Param("filename")
-	path := filepath.Join(tempDir, filename)
+	path := downloadPath(c, c.Param("filename"))
 
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "JSON file not found"})
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var latexDownload = downloadFormat{route: "latex", extension: "zip", label: "LaTeX archive"}
+
+// latexClass is the bundled document class cv.tex is written against.
+const latexClass = "cvbuilder.cls"
+
+func exportLaTeX(c *gin.Context) {
+	var cvData CVData
+	if !bindCV(c, &cvData) {
+		return
+	}
+
+	data, err := writeLaTeXArchive(cvData, parseRenderOptions(c))
+	if err != nil {
+		log.Printf("Error generating LaTeX: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate LaTeX archive"})
+		return
+	}
+	saveDownload(c, latexDownload, data)
+}
+
+// writeLaTeXArchive zips cv.tex together with the class it needs, so the
+// archive compiles with a plain "pdflatex cv.tex" and no TeX is needed here.
+func writeLaTeXArchive(cvData CVData, opts renderOptions) ([]byte, error) {
+	class, err := assetFS.ReadFile("assets/latex/" + latexClass)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", latexClass, err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"cv.tex", []byte(renderLaTeX(cvData, opts))},
+		{latexClass, class},
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, fmt.Errorf("creating %s: %w", file.name, err)
+		}
+		if _, err := w.Write(file.data); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", file.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("closing archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderLaTeX lays the CV out with the sections of cvTemplate. Every value
+// taken from cvData goes through texEscape or texURL.
+func renderLaTeX(cvData CVData, opts renderOptions) string {
+	cvData = prepareCVData(cvData, opts)
+	var b strings.Builder
+	cmd := func(name string, args ...string) {
+		b.WriteString(`\` + name)
+		for _, arg := range args {
+			b.WriteString("{" + arg + "}")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("% Generated by CV Builder. Compile with: pdflatex cv.tex\n")
+	b.WriteString("% The layout lives in " + latexClass + ".\n")
+	cmd("documentclass", "cvbuilder")
+	b.WriteString("\n")
+	cmd("begin", "document")
+	b.WriteString("\n")
+
+	var contact []string
+	for _, value := range []string{cvData.Address, cvData.Phone1, cvData.Phone2} {
+		if value = strings.TrimSpace(value); value != "" {
+			contact = append(contact, texEscape(value))
+		}
+	}
+	if email := strings.TrimSpace(cvData.Email); email != "" {
+		contact = append(contact, `\href{mailto:`+texURL(email)+`}{`+texEscape(email)+`}`)
+	}
+	cmd("makecvheader", texEscape(cvData.Name), strings.Join(contact, `\cvsep{}`))
+
+	b.WriteString("\n")
+	cmd("cvsection", "Personal Statement")
+	if summary := experienceSummary(cvData.Experience, opts); summary != "" {
+		cmd("cvitem", "", `\textit{`+texEscape(summary)+`}`)
+	}
+	if statement := texParagraphs(cvData.Statement); statement != "" {
+		cmd("cvitem", "", statement)
+	}
+
+	if len(cvData.Skills) > 0 {
+		b.WriteString("\n")
+		cmd("cvsection", "Key Skills")
+		for _, skill := range cvData.Skills {
+			cmd("cvlistitem", texEscape(skill.Text))
+		}
+	}
+
+	if len(cvData.Experience) > 0 {
+		b.WriteString("\n")
+		cmd("cvsection", "Professional Experience")
+		for _, exp := range cvData.Experience {
+			cmd("cventry", texEscape(exp.DateRange(opts.DateFormat)), texEscape(exp.Title), texEscape(exp.Company), "")
+			for _, duty := range exp.Duties {
+				cmd("cvlistitem", texEscape(duty.Text))
+			}
+		}
+	}
+
+	if len(cvData.Education) > 0 {
+		b.WriteString("\n")
+		cmd("cvsection", "Education")
+		for _, edu := range cvData.Education {
+			var details []string
+			if edu.Grade != "" {
+				details = append(details, "Grade: "+texEscape(edu.Grade))
+			}
+			if notes := texParagraphs(edu.Notes); notes != "" {
+				details = append(details, notes)
+			}
+			cmd("cventry", texEscape(edu.Period(opts.DateFormat)), texEscape(joinNonEmpty(" in ", edu.Qualification, edu.Field)),
+				texEscape(edu.Institution), strings.Join(details, `\newline `))
+		}
+	}
+
+	if len(cvData.Certifications) > 0 {
+		b.WriteString("\n")
+		cmd("cvsection", "Certifications")
+		for _, cert := range cvData.Certifications {
+			title := texEscape(cert.Name)
+			if opts.flagExpired(cert) {
+				title += `\cvexpired{}`
+			}
+			var details []string
+			if dates := cert.Dates(opts.DateFormat); dates != "" {
+				details = append(details, texEscape(dates))
+			}
+			if cert.CredentialID != "" {
+				details = append(details, "Credential ID "+texEscape(cert.CredentialID))
+			}
+			if cert.URL != "" {
+				details = append(details, `\href{`+texURL(cert.URL)+`}{`+texEscape(cert.URL)+`}`)
+			}
+			cmd("cventry", texEscape(formatDate(cert.Issued, opts.DateFormat)), title, texEscape(cert.Issuer), strings.Join(details, `\newline `))
+		}
+	}
+
+	if len(cvData.Interests) > 0 {
+		b.WriteString("\n")
+		cmd("cvsection", "Personal Interests")
+		for _, interest := range cvData.Interests {
+			cmd("cvlistitem", texEscape(interest))
+		}
+	}
+
+	b.WriteString("\n")
+	cmd("end", "document")
+	return b.String()
+}
+
+var texReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+	`<`, `\textless{}`,
+	`>`, `\textgreater{}`,
+	`|`, `\textbar{}`,
+	"\r", "",
+	"\n", " ",
+)
+
+// texEscape makes s safe to use as text in a LaTeX argument. Line breaks
+// become spaces; use texParagraphs to keep them.
+func texEscape(s string) string {
+	return texReplacer.Replace(strings.TrimSpace(s))
+}
+
+// texParagraphs escapes multi-line text, keeping its line breaks.
+func texParagraphs(s string) string {
+	lines := compactStrings(strings.Split(s, "\n"))
+	for i, line := range lines {
+		lines[i] = texEscape(line)
+	}
+	return strings.Join(lines, `\newline `)
+}
+
+var texURLReplacer = strings.NewReplacer(`\`, "", `{`, "", `}`, "", `%`, `\%`, `#`, `\#`, "\n", "")
+
+// texURL prepares a URL for the first argument of \href, where only % and
+// # need escaping and braces or backslashes cannot appear.
+func texURL(u string) string {
+	return texURLReplacer.Replace(strings.TrimSpace(u))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var odtDownload = downloadFormat{route: "odt", extension: "odt", label: "OpenDocument file"}
+
+const odtMimeType = "application/vnd.oasis.opendocument.text"
+
+func exportODT(c *gin.Context) {
+	var cvData CVData
+	if !bindCV(c, &cvData) {
+		return
+	}
+
+	data, err := writeODT(cvData, parseRenderOptions(c))
+	if err != nil {
+		log.Printf("Error generating ODT: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OpenDocument file"})
+		return
+	}
+	saveDownload(c, odtDownload, data)
+}
+
+// writeODT packages the CV as an OpenDocument text file. The mimetype entry
+// must come first and be stored uncompressed for the package to be valid.
+func writeODT(cvData CVData, opts renderOptions) ([]byte, error) {
+	parts := []struct{ name, content string }{
+		{"mimetype", odtMimeType},
+		{"META-INF/manifest.xml", odtManifest},
+		{"meta.xml", fmt.Sprintf(odtMeta, xmlEscape(cvData.Name+" - CV"), xmlEscape(cvData.Name), opts.Now.UTC().Format(time.RFC3339))},
+		{"styles.xml", odtStyles},
+		{"content.xml", fmt.Sprintf(odtContent, renderODTBody(cvData, opts))},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i, part := range parts {
+		header := &zip.FileHeader{Name: part.name, Method: zip.Deflate}
+		if i == 0 {
+			header.Method = zip.Store
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return nil, fmt.Errorf("creating %s: %w", part.name, err)
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", part.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("closing archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderODTBody mirrors the sections of cvTemplate: the name beside the
+// contact details, then a gold-ruled heading per section.
+func renderODTBody(cvData CVData, opts renderOptions) string {
+	cvData = prepareCVData(cvData, opts)
+	var b strings.Builder
+	p := func(style, text string) {
+		b.WriteString(`<text:p text:style-name="` + style + `">` + xmlEscape(text) + `</text:p>`)
+	}
+	h := func(level int, text string) {
+		fmt.Fprintf(&b, `<text:h text:style-name="Heading_20_%d" text:outline-level="%d">%s</text:h>`, level, level, xmlEscape(text))
+	}
+	list := func(style string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString(`<text:list text:style-name="` + style + `">`)
+		for _, item := range items {
+			b.WriteString(`<text:list-item><text:p text:style-name="List_20_Item">` + xmlEscape(item) + `</text:p></text:list-item>`)
+		}
+		b.WriteString(`</text:list>`)
+	}
+
+	b.WriteString(`<table:table table:name="Contact" table:style-name="Contact"><table:table-column table:number-columns-repeated="2"/><table:table-row>`)
+	b.WriteString(`<table:table-cell office:value-type="string">`)
+	p("Name", cvData.Name)
+	b.WriteString(`</table:table-cell><table:table-cell office:value-type="string">`)
+	for _, line := range compactStrings([]string{cvData.Address, cvData.Phone1, cvData.Phone2, cvData.Email}) {
+		p("Contact", line)
+	}
+	b.WriteString(`</table:table-cell></table:table-row></table:table>`)
+
+	h(2, "Personal Statement")
+	if summary := experienceSummary(cvData.Experience, opts); summary != "" {
+		p("Tenure", summary)
+	}
+	for _, line := range strings.Split(cvData.Statement, "\n") {
+		p("Text_20_body", line)
+	}
+
+	h(2, "Key Skills")
+	list("Skills", texts(cvData.Skills))
+
+	h(2, "Professional Experience")
+	for _, exp := range cvData.Experience {
+		h(3, exp.Title)
+		p("Text_20_body", exp.Company+" - "+exp.DateRange(opts.DateFormat))
+		list("Bullets", texts(exp.Duties))
+	}
+
+	if len(cvData.Education) > 0 {
+		h(2, "Education")
+		for _, edu := range cvData.Education {
+			h(3, joinNonEmpty(" in ", edu.Qualification, edu.Field))
+			p("Text_20_body", joinNonEmpty(" - ", edu.Institution, edu.Period(opts.DateFormat)))
+			if edu.Grade != "" {
+				p("Text_20_body", "Grade: "+edu.Grade)
+			}
+			for _, line := range compactStrings(strings.Split(edu.Notes, "\n")) {
+				p("Text_20_body", line)
+			}
+		}
+	}
+
+	if len(cvData.Certifications) > 0 {
+		h(2, "Certifications")
+		for _, cert := range cvData.Certifications {
+			b.WriteString(`<text:p text:style-name="Certification">` + xmlEscape(cert.Name))
+			if opts.flagExpired(cert) {
+				b.WriteString(`<text:s/><text:span text:style-name="Expired">Expired</text:span>`)
+			}
+			b.WriteString(`</text:p>`)
+			p("Text_20_body", cert.Details(opts.DateFormat))
+			if cert.URL != "" {
+				url := xmlEscape(cert.URL)
+				b.WriteString(`<text:p text:style-name="Text_20_body"><text:a xlink:type="simple" xlink:href="` + url + `">` + url + `</text:a></text:p>`)
+			}
+		}
+	}
+
+	h(2, "Personal Interests")
+	list("Bullets", cvData.Interests)
+	return b.String()
+}
+
+const odtManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">
+<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="application/vnd.oasis.opendocument.text"/>
+<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+<manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>
+<manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/>
+</manifest:manifest>`
+
+// odtMeta takes the title, creator and creation time.
+const odtMeta = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/" office:version="1.2">
+<office:meta><dc:title>%s</dc:title><meta:initial-creator>%s</meta:initial-creator><meta:creation-date>%s</meta:creation-date><meta:generator>CV Builder</meta:generator></office:meta>
+</office:document-meta>`
+
+// odtContent takes the body. The contact table is the only automatic style.
+const odtContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" xmlns:xlink="http://www.w3.org/1999/xlink" office:version="1.2">
+<office:automatic-styles>
+<style:style style:name="Contact" style:family="table"><style:table-properties style:width="17cm" table:align="margins"/></style:style>
+</office:automatic-styles>
+<office:body><office:text>%s</office:text></office:body>
+</office:document-content>`
+
+const odtStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0" office:version="1.2">
+<office:font-face-decls>
+<style:font-face style:name="Arial" svg:font-family="Arial" style:font-family-generic="swiss"/>
+</office:font-face-decls>
+<office:styles>
+<style:default-style style:family="paragraph">
+<style:paragraph-properties fo:margin-top="0cm" fo:margin-bottom="0.1cm"/>
+<style:text-properties style:font-name="Arial" fo:font-size="11pt" fo:language="en" fo:country="GB"/>
+</style:default-style>
+<style:style style:name="Standard" style:family="paragraph" style:class="text"/>
+<style:style style:name="Text_20_body" style:display-name="Text body" style:family="paragraph" style:parent-style-name="Standard" style:class="text">
+<style:paragraph-properties fo:text-align="justify"/>
+</style:style>
+<style:style style:name="Name" style:family="paragraph" style:parent-style-name="Standard">
+<style:text-properties fo:font-size="30pt" fo:font-weight="bold"/>
+</style:style>
+<style:style style:name="Contact" style:family="paragraph" style:parent-style-name="Standard">
+<style:paragraph-properties fo:text-align="end" fo:margin-bottom="0cm"/>
+</style:style>
+<style:style style:name="Heading" style:family="paragraph" style:parent-style-name="Standard" style:class="text">
+<style:paragraph-properties fo:keep-with-next="always"/>
+<style:text-properties fo:font-weight="bold"/>
+</style:style>
+<style:style style:name="Heading_20_2" style:display-name="Heading 2" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="2" style:class="text">
+<style:paragraph-properties fo:margin-top="0.5cm" fo:margin-bottom="0.2cm" fo:padding-bottom="0.05cm" fo:border-bottom="0.06cm solid #ffd700"/>
+<style:text-properties fo:font-size="15pt"/>
+</style:style>
+<style:style style:name="Heading_20_3" style:display-name="Heading 3" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="3" style:class="text">
+<style:paragraph-properties fo:margin-top="0.3cm" fo:margin-bottom="0.1cm"/>
+<style:text-properties fo:font-size="12pt"/>
+</style:style>
+<style:style style:name="Tenure" style:family="paragraph" style:parent-style-name="Text_20_body">
+<style:text-properties fo:font-style="italic"/>
+</style:style>
+<style:style style:name="Certification" style:family="paragraph" style:parent-style-name="Standard">
+<style:paragraph-properties fo:margin-top="0.2cm" fo:keep-with-next="always"/>
+<style:text-properties fo:font-weight="bold"/>
+</style:style>
+<style:style style:name="List_20_Item" style:display-name="List Item" style:family="paragraph" style:parent-style-name="Standard" style:class="list">
+<style:paragraph-properties fo:margin-bottom="0.05cm"/>
+</style:style>
+<style:style style:name="Expired" style:family="text">
+<style:text-properties fo:color="#c0392b" fo:font-size="8pt" fo:font-weight="normal"/>
+</style:style>
+<text:list-style style:name="Skills">
+<text:list-level-style-bullet text:level="1" text:bullet-char="✓">
+<style:list-level-properties text:list-level-position-and-space-mode="label-alignment"><style:list-level-label-alignment text:label-followed-by="listtab" text:list-tab-stop-position="0.6cm" fo:text-indent="-0.6cm" fo:margin-left="0.6cm"/></style:list-level-properties>
+<style:text-properties fo:color="#32cd32" fo:font-weight="bold"/>
+</text:list-level-style-bullet>
+</text:list-style>
+<text:list-style style:name="Bullets">
+<text:list-level-style-bullet text:level="1" text:bullet-char="•">
+<style:list-level-properties text:list-level-position-and-space-mode="label-alignment"><style:list-level-label-alignment text:label-followed-by="listtab" text:list-tab-stop-position="1.2cm" fo:text-indent="-0.6cm" fo:margin-left="1.2cm"/></style:list-level-properties>
+</text:list-level-style-bullet>
+</text:list-style>
+</office:styles>
+<office:automatic-styles>
+<style:page-layout style:name="A4"><style:page-layout-properties fo:page-width="21cm" fo:page-height="29.7cm" fo:margin-top="2cm" fo:margin-bottom="2cm" fo:margin-left="2cm" fo:margin-right="2cm"/></style:page-layout>
+</office:automatic-styles>
+<office:master-styles>
+<style:master-page style:name="Standard" style:page-layout-name="A4"/>
+</office:master-styles>
+</office:document-styles>`
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	textDownload     = downloadFormat{route: "text", extension: "txt", label: "text file"}
+	textHTMLDownload = downloadFormat{route: "text", extension: "html", label: "HTML file"}
+)
+
+// exportText renders the CV as single-column plain text for applicant
+// tracking systems, or as equally plain HTML with format=html. Render
+// options such as date_format and tag filters apply as for PDFs.
+func exportText(c *gin.Context) {
+	var cvData CVData
+	if !bindCV(c, &cvData) {
+		return
+	}
+
+	outline := buildOutline(cvData, parseRenderOptions(c))
+	switch c.DefaultQuery("format", "text") {
+	case "text":
+		saveDownload(c, textDownload, []byte(renderOutlineText(outline)))
+	case "html":
+		saveDownload(c, textHTMLDownload, []byte(renderOutlineHTML(outline)))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown format"})
+	}
+}
+
+// downloadText serves both plain variants from one route, picking the
+// attachment type from the saved file's extension.
+func downloadText(c *gin.Context) {
+	if strings.HasSuffix(c.Param("filename"), "."+textHTMLDownload.extension) {
+		serveDownload(textHTMLDownload)(c)
+		return
+	}
+	serveDownload(textDownload)(c)
+}
+
+func renderOutlineText(outline cvOutline) string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(outline.Name) + "\n")
+	if len(outline.Contact) > 0 {
+		b.WriteString(strings.Join(outline.Contact, " | ") + "\n")
+	}
+	for _, section := range outline.Sections {
+		b.WriteString("\n" + strings.ToUpper(section.Title) + "\n")
+		for i, block := range section.Blocks {
+			if i > 0 && block.Heading != "" {
+				b.WriteString("\n")
+			}
+			if block.Heading != "" {
+				b.WriteString(block.Heading + "\n")
+			}
+			for _, line := range block.Lines {
+				b.WriteString(line + "\n")
+			}
+			for _, bullet := range block.Bullets {
+				b.WriteString("- " + bullet + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// renderOutlineHTML produces semantic HTML with no styles, scripts, tables
+// or images.
+func renderOutlineHTML(outline cvOutline) string {
+	var b strings.Builder
+	esc := html.EscapeString
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"UTF-8\">\n")
+	b.WriteString("<title>" + esc(outline.Name) + " - CV</title>\n</head>\n<body>\n")
+	b.WriteString("<h1>" + esc(outline.Name) + "</h1>\n")
+	if len(outline.Contact) > 0 {
+		b.WriteString("<p>" + esc(strings.Join(outline.Contact, " | ")) + "</p>\n")
+	}
+	for _, section := range outline.Sections {
+		b.WriteString("<h2>" + esc(section.Title) + "</h2>\n")
+		for _, block := range section.Blocks {
+			if block.Heading != "" {
+				b.WriteString("<h3>" + esc(block.Heading) + "</h3>\n")
+			}
+			for _, line := range block.Lines {
+				b.WriteString("<p>" + esc(line) + "</p>\n")
+			}
+			if len(block.Bullets) > 0 {
+				b.WriteString("<ul>\n")
+				for _, bullet := range block.Bullets {
+					b.WriteString("<li>" + esc(bullet) + "</li>\n")
+				}
+				b.WriteString("</ul>\n")
+			}
+		}
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
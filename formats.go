@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	yamlDownload = downloadFormat{route: "yaml", extension: "yaml", label: "YAML file"}
+	tomlDownload = downloadFormat{route: "toml", extension: "toml", label: "TOML file"}
+)
+
+// bodyFormat picks the decoder for a CV request body from its Content-Type.
+// Anything that is not YAML or TOML is read as JSON, as before.
+func bodyFormat(c *gin.Context) string {
+	switch c.ContentType() {
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return "YAML"
+	case "application/toml":
+		return "TOML"
+	default:
+		return "JSON"
+	}
+}
+
+// bindCV decodes a CV from the request body in JSON, YAML or TOML, writing
+// a 400 response itself when the body cannot be decoded, or a 413 when it is
+// larger than maxImportSize.
+func bindCV(c *gin.Context, cvData *CVData) bool {
+	var ok bool
+	*cvData, _, ok = readCVBody(c)
+	return ok
+}
+
+// readCVBody is bindCV for handlers that also want the YAML document the CV
+// was read from, so they can carry its comments over. The node is nil for
+// other formats. YAML and TOML are decoded strictly because they are written
+// by hand, and their errors are returned to the client with line numbers.
+func readCVBody(c *gin.Context) (CVData, *yaml.Node, bool) {
+	var cvData CVData
+	format := bodyFormat(c)
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxImportSize+1))
+	if len(body) > maxImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Request body exceeds %d bytes", maxImportSize)})
+		return CVData{}, nil, false
+	}
+	if err == nil {
+		var source *yaml.Node
+		if source, err = decodeCV(body, format, &cvData); err == nil {
+			return cvData, source, true
+		}
+	}
+
+	log.Printf("Error binding %s: %v", format, err)
+	message := "Invalid " + format + " data"
+	if format != "JSON" {
+		message += ": " + err.Error()
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": message})
+	return CVData{}, nil, false
+}
+
+func decodeCV(body []byte, format string, cvData *CVData) (*yaml.Node, error) {
+	switch format {
+	case "YAML":
+		var source yaml.Node
+		if err := yaml.Unmarshal(body, &source); err != nil {
+			return nil, err
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(body))
+		dec.KnownFields(true)
+		if err := dec.Decode(cvData); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("empty document")
+			}
+			return nil, err
+		}
+		return &source, nil
+	case "TOML":
+		dec := toml.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+		dec.EnableUnmarshalerInterface()
+		return nil, tomlError(dec.Decode(cvData))
+	default:
+		return nil, json.Unmarshal(body, cvData)
+	}
+}
+
+// tomlError adds the line number to go-toml's errors, which leave it out of
+// their messages.
+func tomlError(err error) error {
+	var decodeErr *toml.DecodeError
+	var strictErr *toml.StrictMissingError
+	switch {
+	case errors.As(err, &strictErr) && len(strictErr.Errors) > 0:
+		missing := strictErr.Errors[0]
+		line, _ := missing.Position()
+		return fmt.Errorf("line %d: unknown field %q", line, strings.Join(missing.Key(), "."))
+	case errors.As(err, &decodeErr):
+		line, _ := decodeErr.Position()
+		return fmt.Errorf("line %d: %s", line, decodeErr.Error())
+	}
+	return err
+}
+
+// encodeYAML writes cvData as YAML. When source is the document the CV was
+// read from, its comments are copied onto the matching keys and list items.
+func encodeYAML(cvData CVData, source *yaml.Node) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(cvData); err != nil {
+		return nil, err
+	}
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&node}}
+	if source != nil {
+		copyComments(doc, source)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// copyComments copies the comments of src onto dst, matching mapping entries
+// by key and sequence items by position. Comments on content that no longer
+// exists are dropped.
+func copyComments(dst, src *yaml.Node) {
+	dst.HeadComment, dst.LineComment, dst.FootComment = src.HeadComment, src.LineComment, src.FootComment
+	if dst.Kind != src.Kind {
+		return
+	}
+	switch dst.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for i := 0; i < len(dst.Content) && i < len(src.Content); i++ {
+			copyComments(dst.Content[i], src.Content[i])
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			for j := 0; j+1 < len(src.Content); j += 2 {
+				if dst.Content[i].Value == src.Content[j].Value {
+					copyComments(dst.Content[i], src.Content[j])
+					copyComments(dst.Content[i+1], src.Content[j+1])
+					break
+				}
+			}
+		}
+	}
+}
+
+// encodeTOML writes cvData as TOML. The TOML encoder has no comment support,
+// so comments in a TOML source are not carried over.
+func encodeTOML(cvData CVData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(cvData); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func exportYAML(c *gin.Context) {
+	cvData, source, ok := readCVBody(c)
+	if !ok {
+		return
+	}
+
+	data, err := encodeYAML(cvData, source)
+	if err != nil {
+		log.Printf("Error generating YAML: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate YAML"})
+		return
+	}
+	saveDownload(c, yamlDownload, data)
+}
+
+func exportTOML(c *gin.Context) {
+	var cvData CVData
+	if !bindCV(c, &cvData) {
+		return
+	}
+
+	data, err := encodeTOML(cvData)
+	if err != nil {
+		log.Printf("Error generating TOML: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate TOML"})
+		return
+	}
+	saveDownload(c, tomlDownload, data)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// bindTestCV runs readCVBody on a request with the given body and
+// Content-Type, returning the decoded CV and the response it wrote.
+func bindTestCV(t *testing.T, contentType, body string) (CVData, bool, *httptest.ResponseRecorder) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", contentType)
+	cvData, _, ok := readCVBody(c)
+	return cvData, ok, w
+}
+
+func TestReadCVBodyFormats(t *testing.T) {
+	want := CVData{
+		Name:   "Jane Doe",
+		Skills: []TaggedText{{Text: "Go", Tags: []string{"backend"}}, {Text: "SQL"}},
+	}
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{"application/json", `{"name":"Jane Doe","skills":[{"text":"Go","tags":["backend"]},"SQL"]}`},
+		{"application/yaml; charset=utf-8", "name: Jane Doe\nskills:\n  - text: Go\n    tags: [backend]\n  - SQL\n"},
+		{"text/yaml", "name: Jane Doe\nskills: [{text: Go, tags: [backend]}, SQL]\n"},
+		{"application/toml", "name = \"Jane Doe\"\nskills = [{text = \"Go\", tags = [\"backend\"]}, \"SQL\"]\n"},
+		{"application/toml", "name = \"Jane Doe\"\n[[skills]]\ntext = \"Go\"\ntags = [\"backend\"]\n[[skills]]\ntext = \"SQL\"\n"},
+	}
+	for _, tt := range tests {
+		got, ok, w := bindTestCV(t, tt.contentType, tt.body)
+		if !ok {
+			t.Errorf("%s: failed with %d %s", tt.contentType, w.Code, w.Body)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: decoded %+v, want %+v", tt.contentType, got, want)
+		}
+	}
+}
+
+func TestReadCVBodyErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		message     string
+	}{
+		{"yaml unknown field", "application/yaml", "name: A\nnickname: B\n", "line 2"},
+		{"yaml unknown item field", "application/yaml", "skills:\n  - text: Go\n    level: 3\n", "unknown field \"level\""},
+		{"yaml empty", "application/yaml", "", "empty document"},
+		{"toml unknown field", "application/toml", "name = \"A\"\nnickname = \"B\"\n", "line 2: unknown field \"nickname\""},
+		{"toml syntax", "application/toml", "name = \n", "line 1"},
+		{"toml bad item", "application/toml", "skills = [1]\n", "expected a string or a table"},
+		{"json syntax", "application/json", "{", "Invalid JSON data"},
+	}
+	for _, tt := range tests {
+		_, ok, w := bindTestCV(t, tt.contentType, tt.body)
+		var response struct{ Error string }
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if ok || w.Code != http.StatusBadRequest || !strings.Contains(response.Error, tt.message) {
+			t.Errorf("%s: got %v, %d %s; want 400 mentioning %q", tt.name, ok, w.Code, w.Body, tt.message)
+		}
+	}
+}
+
+func TestReadCVBodyTooLarge(t *testing.T) {
+	body := `{"name":"` + strings.Repeat("a", maxImportSize) + `"}`
+	_, ok, w := bindTestCV(t, "application/json", body)
+	if ok || w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got %v, %d; want 413", ok, w.Code)
+	}
+}
+
+func TestEncodeYAMLKeepsComments(t *testing.T) {
+	source := "# My CV\nname: Jane Doe # full name\nskills:\n  # strongest first\n  - Go\n"
+	cvData, ok, w := bindTestCV(t, "application/yaml", source)
+	if !ok {
+		t.Fatalf("decode failed: %s", w.Body)
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(source))
+	c.Request.Header.Set("Content-Type", "application/yaml")
+	_, node, _ := readCVBody(c)
+
+	out, err := encodeYAML(cvData, node)
+	if err != nil {
+		t.Fatalf("encodeYAML: %v", err)
+	}
+	for _, comment := range []string{"# My CV", "# full name", "# strongest first"} {
+		if !strings.Contains(string(out), comment) {
+			t.Errorf("comment %q missing from:\n%s", comment, out)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	want := CVData{
+		Name:       "Jane Doe",
+		Statement:  "Line one\nLine two",
+		Skills:     []TaggedText{{Text: "Go", Tags: []string{"backend"}}, {Text: "SQL"}},
+		Experience: []Experience{{Title: "Engineer", Start: &YearMonth{Year: 2020, Month: 3}, End: &YearMonth{Present: true}, Duties: untagged([]string{"Shipped"})}},
+		Interests:  []string{"Climbing"},
+	}
+
+	yamlData, err := encodeYAML(want, nil)
+	if err != nil {
+		t.Fatalf("encodeYAML: %v", err)
+	}
+	if got, ok, w := bindTestCV(t, "application/yaml", string(yamlData)); !ok || !sameCV(got, want) {
+		t.Errorf("YAML round trip gave %+v (%s), want %+v", got, w.Body, want)
+	}
+	if !strings.Contains(string(yamlData), "- SQL\n") {
+		t.Errorf("untagged skill not written as a plain string:\n%s", yamlData)
+	}
+
+	tomlData, err := encodeTOML(want)
+	if err != nil {
+		t.Fatalf("encodeTOML: %v", err)
+	}
+	if got, ok, w := bindTestCV(t, "application/toml", string(tomlData)); !ok || !sameCV(got, want) {
+		t.Errorf("TOML round trip gave %+v (%s), want %+v", got, w.Body, want)
+	}
+}
+
+// sameCV compares CVs after normalizing them, which treats nil and empty
+// lists alike.
+func sameCV(a, b CVData) bool {
+	normalizeCVData(&a)
+	normalizeCVData(&b)
+	return reflect.DeepEqual(a, b)
+}
@@ -1,5 +1,6 @@
 import React, { useState } from 'react';
 import { Box, Container, CssBaseline, ThemeProvider } from '@mui/material';
+import AuthGate from './components/AuthGate';
 import CVBuilder from './components/CVBuilder';
 import { darkTheme, lightTheme } from './styles/theme';
 import Header from './components/Header';
@@ -31,7 +32,9 @@ const App: React.FC = () => {
             },
           }}
         >
-          <CVBuilder />
+          <AuthGate>
+            <CVBuilder />
+          </AuthGate>
         </Container>
       </Box>
     </ThemeProvider>
@@ -0,0 +1,224 @@
+import React, { FormEvent, useEffect, useState } from 'react';
+import { Alert, Box, Button, CircularProgress, Link, Paper, TextField, Typography } from '@mui/material';
+
+interface User {
+  id: string;
+  email: string;
+  name: string;
+}
+
+type Mode = 'signIn' | 'register' | 'forgot' | 'reset';
+
+const titles: Record<Mode, string> = {
+  signIn: 'Sign in',
+  register: 'Create an account',
+  forgot: 'Reset your password',
+  reset: 'Choose a new password',
+};
+
+const submitLabels: Record<Mode, string> = {
+  signIn: 'Sign in',
+  register: 'Create account',
+  forgot: 'Send reset link',
+  reset: 'Change password',
+};
+
+const apiUrl = (path: string) => {
+  let prefix = window.location.origin;
+  const u = new URL(window.location.href);
+  if (u.port) {
+    prefix = `${u.protocol}//${u.hostname}`;
+  }
+  return `${prefix}${path}`;
+};
+
+const post = (path: string, body: object) =>
+  fetch(apiUrl(path), {
+    method: 'POST',
+    credentials: 'include',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify(body),
+  });
+
+const errorMessage = async (response: Response) => {
+  try {
+    const data = await response.json();
+    const problems = (data.problems ?? [])
+      .map((p: { field: string; message: string }) => `${p.field} ${p.message}`.trim())
+      .join(', ');
+    return `${data.error}${problems ? `: ${problems}` : ''}`;
+  } catch (error) {
+    return response.statusText || 'Something went wrong. Please try again.';
+  }
+};
+
+// AuthGate shows the sign-in form until the user has a session, then renders
+// its children. Links from password reset emails open it with ?reset_token=.
+const AuthGate: React.FC<{ children: React.ReactNode }> = ({ children }) => {
+  const resetToken = new URLSearchParams(window.location.search).get('reset_token');
+  const [user, setUser] = useState<User | null>(null);
+  const [isLoading, setIsLoading] = useState<boolean>(true);
+  const [isSubmitting, setIsSubmitting] = useState<boolean>(false);
+  const [mode, setMode] = useState<Mode>(resetToken ? 'reset' : 'signIn');
+  const [name, setName] = useState<string>('');
+  const [email, setEmail] = useState<string>('');
+  const [password, setPassword] = useState<string>('');
+  const [error, setError] = useState<string>('');
+  const [notice, setNotice] = useState<string>('');
+
+  useEffect(() => {
+    fetch(apiUrl('/api/auth/me'), { credentials: 'include' })
+      .then((response) => (response.ok ? response.json() : null))
+      .then((data) => setUser(data?.user ?? null))
+      .catch((error) => console.error('Failed to load session:', error))
+      .finally(() => setIsLoading(false));
+  }, []);
+
+  const switchMode = (next: Mode) => {
+    setMode(next);
+    setPassword('');
+    setError('');
+    setNotice('');
+  };
+
+  const handleSubmit = async (e: FormEvent) => {
+    e.preventDefault();
+    setIsSubmitting(true);
+    setError('');
+    setNotice('');
+    try {
+      if (mode === 'signIn' || mode === 'register') {
+        const response =
+          mode === 'signIn'
+            ? await post('/api/auth/login', { email, password })
+            : await post('/api/auth/register', { email, password, name });
+        if (response.ok) {
+          const data = await response.json();
+          setPassword('');
+          setUser(data.user);
+        } else {
+          setError(await errorMessage(response));
+        }
+      } else if (mode === 'forgot') {
+        const response = await post('/api/auth/password-reset', { email });
+        if (response.ok) {
+          setNotice('If an account exists for that email, a reset link is on its way.');
+        } else {
+          setError(await errorMessage(response));
+        }
+      } else {
+        const response = await post('/api/auth/password-reset/confirm', { token: resetToken, password });
+        if (response.ok) {
+          window.history.replaceState(null, '', window.location.pathname);
+          switchMode('signIn');
+          setNotice('Your password has been changed. Sign in with your new password.');
+        } else {
+          setError(await errorMessage(response));
+        }
+      }
+    } catch (error) {
+      console.error('Authentication request failed:', error);
+      setError('Failed to get response from server. Please try again.');
+    } finally {
+      setIsSubmitting(false);
+    }
+  };
+
+  const signOut = async () => {
+    try {
+      await fetch(apiUrl('/api/auth/logout'), { method: 'POST', credentials: 'include' });
+    } catch (error) {
+      console.error('Failed to sign out:', error);
+    }
+    // Reloading clears the previous user's CV from the page.
+    window.location.reload();
+  };
+
+  if (isLoading) {
+    return (
+      <Box display='flex' justifyContent='center' my={6}>
+        <CircularProgress />
+      </Box>
+    );
+  }
+
+  if (user) {
+    return (
+      <>
+        <Box display='flex' alignItems='center' justifyContent='flex-end' gap={1} mt={2} px={{ xs: 1, sm: 0 }}>
+          <Typography variant='body2' color='text.secondary'>
+            Signed in as {user.name || user.email}
+          </Typography>
+          <Button size='small' onClick={signOut}>
+            Sign out
+          </Button>
+        </Box>
+        {children}
+      </>
+    );
+  }
+
+  return (
+    <Paper elevation={3} sx={{ p: { xs: 2, sm: 3 }, my: 4, mx: 'auto', maxWidth: 420 }}>
+      <Typography variant='h5' gutterBottom>
+        {titles[mode]}
+      </Typography>
+      <Box component='form' onSubmit={handleSubmit} display='flex' flexDirection='column' gap={2}>
+        {error && <Alert severity='error'>{error}</Alert>}
+        {notice && <Alert severity='success'>{notice}</Alert>}
+        {mode === 'register' && (
+          <TextField
+            label='Name'
+            value={name}
+            autoComplete='name'
+            onChange={(e) => setName(e.target.value)}
+          />
+        )}
+        {mode !== 'reset' && (
+          <TextField
+            required
+            label='Email'
+            type='email'
+            value={email}
+            autoComplete='email'
+            onChange={(e) => setEmail(e.target.value)}
+          />
+        )}
+        {mode !== 'forgot' && (
+          <TextField
+            required
+            label={mode === 'reset' ? 'New password' : 'Password'}
+            type='password'
+            value={password}
+            autoComplete={mode === 'signIn' ? 'current-password' : 'new-password'}
+            helperText={mode === 'signIn' ? undefined : 'At least 8 characters'}
+            onChange={(e) => setPassword(e.target.value)}
+          />
+        )}
+        <Button type='submit' variant='contained' disabled={isSubmitting}>
+          {submitLabels[mode]}
+        </Button>
+        <Box display='flex' justifyContent='space-between'>
+          {mode === 'signIn' ? (
+            <>
+              <Link component='button' type='button' variant='body2' onClick={() => switchMode('register')}>
+                Create an account
+              </Link>
+              <Link component='button' type='button' variant='body2' onClick={() => switchMode('forgot')}>
+                Forgot password?
+              </Link>
+            </>
+          ) : (
+            <Link component='button' type='button' variant='body2' onClick={() => switchMode('signIn')}>
+              Back to sign in
+            </Link>
+          )}
+        </Box>
+      </Box>
+    </Paper>
+  );
+};
+
+export default AuthGate;
@@ -166,7 +166,7 @@ export const useCVBuilder = () => {
   };
 
   const getResponse = useCallback(
-    async (url: string) => {
+    async (url: string, accept = 'application/json') => {
       try {
         let prefix = window.location.origin;
         const u = new URL(window.location.href);
@@ -174,13 +174,20 @@ export const useCVBuilder = () => {
           prefix = `${u.protocol}//${u.hostname}`;
         }
 
-        return await fetch(`${prefix}${url}`, {
+        const response = await fetch(`${prefix}${url}`, {
           method: 'POST',
+          credentials: 'include',
           headers: {
             'Content-Type': 'application/json',
+            Accept: accept,
           },
           body: JSON.stringify(cvData),
         });
+        if (response.status === 401) {
+          alert('Your session has expired. Please sign in again.');
+          window.location.reload();
+        }
+        return response;
       } catch (error) {
         console.error('Failed to get response:', error);
         alert('Failed to get response from server. Please try again.');
@@ -192,14 +199,16 @@ export const useCVBuilder = () => {
   const generatePDF = useCallback(async () => {
     try {
       setIsGeneratingPDF(true);
-      const response = await getResponse('/api/generate-pdf');
+      const response = isMobile
+        ? await getResponse('/api/generate-pdf?preview=false')
+        : await getResponse('/api/generate-pdf?disposition=inline', 'application/pdf');
       if (response && response.ok) {
-        const data = await response.json();
         if (isMobile) {
+          const data = await response.json();
           handleMobileDownload(data.download_link, `${cvData.name.replace(/\s+/g, '_')}_CV.pdf`);
         } else {
-          const pdfContent = `data:application/pdf;base64,${data.pdf_preview}`;
-          setPdfBlob(pdfContent);
+          const pdf = await response.blob();
+          setPdfBlob(URL.createObjectURL(pdf));
           setOpenPdfDialog(true);
         }
       } else {
@@ -247,20 +256,36 @@ export const useCVBuilder = () => {
   }, [cvData, isMobile, getResponse]);
 
   const importJSON = useCallback(
-    (event: ChangeEvent<HTMLInputElement>) => {
+    async (event: ChangeEvent<HTMLInputElement>) => {
       const file = event.target.files?.[0];
       if (file) {
-        const reader = new FileReader();
-        reader.onload = (e: ProgressEvent<FileReader>) => {
-          try {
-            const importedData = JSON.parse(e.target?.result as string) as CVData;
-            updateCvData(importedData);
-          } catch (error) {
-            console.error('Error parsing JSON:', error);
-            alert('Error importing data. Please make sure the file is a valid JSON.');
+        try {
+          let prefix = window.location.origin;
+          const u = new URL(window.location.href);
+          if (u.port) {
+            prefix = `${u.protocol}//${u.hostname}`;
           }
-        };
-        reader.readAsText(file);
+
+          const formData = new FormData();
+          formData.append('file', file);
+          const response = await fetch(`${prefix}/api/import-json`, {
+            method: 'POST',
+            credentials: 'include',
+            body: formData,
+          });
+          const data = await response.json();
+          if (response.ok) {
+            updateCvData(data.cv as CVData);
+          } else {
+            const problems = (data.problems ?? [])
+              .map((p: { field: string; message: string }) => `${p.field} ${p.message}`.trim())
+              .join('\n');
+            alert(`Error importing data. ${data.error}${problems ? `:\n${problems}` : ''}`);
+          }
+        } catch (error) {
+          console.error('Error importing JSON:', error);
+          alert('Error importing data. Please make sure the file is a valid JSON.');
+        }
       }
     },
     [updateCvData]
@@ -2,11 +2,13 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
-	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -19,6 +21,7 @@ import (
 const (
 	tempDir        = "./.temp"
 	expirationTime = 15 * time.Minute
+	mimePDF        = "application/pdf"
 )
 
 func init() {
@@ -31,98 +34,117 @@ func init() {
 
 func generatePDF(c *gin.Context) {
 	var cvData CVData
-	if err := c.BindJSON(&cvData); err != nil {
-		log.Printf("Error binding JSON: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !bindCV(c, &cvData) {
 		return
 	}
 
-	var buf bytes.Buffer
-	if err := cvTemplate(cvData).Render(c.Request.Context(), &buf); err != nil {
+	respondWithPDF(c, cvData)
+}
+
+// respondWithPDF renders cvData with the request's render options and either
+// streams the PDF or saves it for download, depending on the Accept header.
+func respondWithPDF(c *gin.Context, cvData CVData) {
+	html, err := renderCVHTML(c.Request.Context(), cvData, parseRenderOptions(c))
+	if errors.Is(err, errUnknownTemplate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown template"})
+		return
+	}
+	if err != nil {
 		log.Printf("Error rendering template: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate HTML"})
 		return
 	}
 
-	gotenbergURL := getEnv("GOTENBERG_URL", "http://gotenberg:3000")
-	gotenbergEndpoint := fmt.Sprintf("%s/forms/chromium/convert/html", gotenbergURL)
-	payload := &bytes.Buffer{}
-	writer := multipart.NewWriter(payload)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), renderTimeout)
+	defer cancel()
 
-	part, err := writer.CreateFormFile("files", "index.html")
+	pdf, err := renderer.Render(ctx, html)
 	if err != nil {
-		log.Printf("Error creating form file: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request for Gotenberg"})
+		log.Printf("Error rendering PDF with %s: %v", renderer.Name(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
 		return
 	}
+	defer pdf.Close()
 
-	_, err = io.Copy(part, &buf)
-	if err != nil {
-		log.Printf("Error writing HTML to form file: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request for Gotenberg"})
+	if c.NegotiateFormat(gin.MIMEJSON, mimePDF) == mimePDF {
+		streamPDF(c, pdf)
 		return
 	}
 
-	err = writer.Close()
-	if err != nil {
-		log.Printf("Error closing multipart writer: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request for Gotenberg"})
-		return
+	filename := fmt.Sprintf("%s.pdf", uuid.New().String())
+	path, err := newDownloadPath(c, filename)
+	var file *os.File
+	if err == nil {
+		file, err = os.Create(path)
 	}
-
-	gotenbergReq, err := http.NewRequest("POST", gotenbergEndpoint, payload)
 	if err != nil {
-		log.Printf("Error creating request for Gotenberg: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request for Gotenberg"})
+		log.Printf("Error saving PDF: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save PDF"})
 		return
 	}
-	gotenbergReq.Header.Set("Content-Type", writer.FormDataContentType())
 
-	client := &http.Client{}
-	resp, err := client.Do(gotenbergReq)
-	if err != nil {
-		log.Printf("Error sending request to Gotenberg: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send request to Gotenberg"})
-		return
+	// The base64 preview is encoded while the PDF is written to disk, but it
+	// is still held in memory in full, a third larger than the PDF itself.
+	// Only preview=false and the Accept: application/pdf stream above avoid
+	// buffering the document.
+	var w io.Writer = file
+	var preview bytes.Buffer
+	withPreview := c.DefaultQuery("preview", "true") != "false"
+	encoder := base64.NewEncoder(base64.StdEncoding, &preview)
+	if withPreview {
+		w = io.MultiWriter(file, encoder)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		log.Printf("Gotenberg returned non-OK status: %d, Body: %s", resp.StatusCode, string(bodyBytes))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Gotenberg returned non-OK status: %d", resp.StatusCode)})
-		return
+	_, err = io.Copy(w, pdf)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
 	}
-
-	pdfBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Error reading PDF from Gotenberg response: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read PDF from Gotenberg"})
+		log.Printf("Error saving PDF from %s: %v", renderer.Name(), err)
+		os.Remove(path)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save PDF"})
 		return
 	}
+	encoder.Close()
 
-	filename := fmt.Sprintf("%s.pdf", uuid.New().String())
-	path := filepath.Join(tempDir, filename)
-
-	if err := os.WriteFile(path, pdfBytes, 0644); err != nil {
-		log.Printf("Error saving PDF: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save PDF"})
-		return
+	response := gin.H{
+		"download_link": fmt.Sprintf("/download-pdf/%s", filename),
 	}
+	if withPreview {
+		response["pdf_preview"] = preview.String()
+	}
+	c.JSON(http.StatusOK, response)
+}
 
-	downloadLink := fmt.Sprintf("/download-pdf/%s", filename)
+// renderCVHTML renders the CV with the template selected in opts into a
+// complete HTML document.
+func renderCVHTML(ctx context.Context, cvData CVData, opts renderOptions) (*bytes.Buffer, error) {
+	template, ok := lookupTemplate(opts.Template)
+	if !ok {
+		return nil, errUnknownTemplate
+	}
 
-	pdfBase64 := base64.StdEncoding.EncodeToString(pdfBytes)
+	var buf bytes.Buffer
+	if err := template.component(prepareCVData(cvData, opts), opts).Render(ctx, &buf); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"pdf_preview":   pdfBase64,
-		"download_link": downloadLink,
+// streamPDF forwards the renderer output straight to the client. Pass
+// disposition=inline to display the PDF in the browser instead of saving it.
+func streamPDF(c *gin.Context, pdf io.Reader) {
+	disposition := "attachment"
+	if c.Query("disposition") == "inline" {
+		disposition = "inline"
+	}
+	c.DataFromReader(http.StatusOK, -1, mimePDF, pdf, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`%s; filename="cv.pdf"`, disposition),
 	})
 }
 
 func downloadPDF(c *gin.Context) {
-	filename := c.Param("filename")
-	path := filepath.Join(tempDir, filename)
+	path := downloadPath(c, c.Param("filename"))
 
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "PDF not found"})
@@ -132,35 +154,38 @@ func downloadPDF(c *gin.Context) {
 	c.FileAttachment(path, "cv.pdf")
 }
 
+// cleanupExpiredPDFs deletes generated files, which live in per-user
+// directories under tempDir, once they are older than expirationTime.
 func cleanupExpiredPDFs() {
 	for {
 		time.Sleep(5 * time.Minute)
 
-		files, err := os.ReadDir(tempDir)
-		if err != nil {
-			log.Printf("Error reading temp directory: %v", err)
-			continue
-		}
-
-		for _, file := range files {
-			if file.IsDir() {
-				continue
+		err := filepath.WalkDir(tempDir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				log.Printf("Error reading temp directory: %v", err)
+				return nil
+			}
+			if entry.IsDir() {
+				return nil
 			}
 
-			path := filepath.Join(tempDir, file.Name())
-			info, err := os.Stat(path)
+			info, err := entry.Info()
 			if err != nil {
 				log.Printf("Error getting file info: %v", err)
-				continue
+				return nil
 			}
 
 			if time.Since(info.ModTime()) > expirationTime {
 				if err := os.Remove(path); err != nil {
 					log.Printf("Error removing expired file: %v", err)
 				} else {
-					log.Printf("Removed expired file: %s", file.Name())
+					log.Printf("Removed expired file: %s", entry.Name())
 				}
 			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Error cleaning temp directory: %v", err)
 		}
 	}
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// gotenbergRenderer renders PDFs through a Gotenberg instance's Chromium
+// HTML conversion route. Requests are bounded by the context passed to
+// Render rather than a client timeout, which would also cut off the PDF
+// while it is streamed to the caller.
+type gotenbergRenderer struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newGotenbergRenderer(baseURL string) *gotenbergRenderer {
+	return &gotenbergRenderer{
+		endpoint: fmt.Sprintf("%s/forms/chromium/convert/html", baseURL),
+		client:   &http.Client{},
+	}
+}
+
+func (r *gotenbergRenderer) Name() string {
+	return "gotenberg"
+}
+
+func (r *gotenbergRenderer) Render(ctx context.Context, html io.Reader) (io.ReadCloser, error) {
+	payload := &bytes.Buffer{}
+	writer := multipart.NewWriter(payload)
+
+	part, err := writer.CreateFormFile("files", "index.html")
+	if err != nil {
+		return nil, fmt.Errorf("creating form file: %w", err)
+	}
+	if _, err := io.Copy(part, html); err != nil {
+		return nil, fmt.Errorf("writing HTML to form file: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("closing multipart writer: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.endpoint, payload)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("non-OK status: %d, body: %s", resp.StatusCode, string(body))
+	}
+	return resp.Body, nil
+}
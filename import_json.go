@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportSize = 1 << 20
+
+// fieldError describes one problem with an imported document. Line is set
+// for line-oriented formats such as Markdown.
+type fieldError struct {
+	Field   string `json:"field"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func importJSON(c *gin.Context) {
+	cvData, ok := bindCVData(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cv": cvData,
+	})
+}
+
+// bindCVData reads, validates and normalises a CV document from the request,
+// writing the error response itself when the document is unusable.
+func bindCVData(c *gin.Context) (CVData, bool) {
+	body, err := readImportBody(c)
+	if err != nil {
+		log.Printf("Error reading import body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return CVData{}, false
+	}
+
+	cvData, problems := decodeCVData(body)
+	if len(problems) == 0 {
+		problems = validateCVData(cvData)
+	}
+	if len(problems) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Invalid CV data",
+			"problems": problems,
+		})
+		return CVData{}, false
+	}
+
+	normalizeCVData(&cvData)
+	return cvData, true
+}
+
+// readImportBody returns the uploaded document, taken from the "file" form
+// field for multipart requests and from the raw request body otherwise.
+func readImportBody(c *gin.Context) ([]byte, error) {
+	var r io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New("missing file upload")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, errors.New("failed to open uploaded file")
+		}
+		defer file.Close()
+		r = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxImportSize+1))
+	if err != nil {
+		return nil, errors.New("failed to read uploaded file")
+	}
+	if len(data) > maxImportSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxImportSize)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.New("empty document")
+	}
+	return data, nil
+}
+
+// decodeCVData strictly decodes data into a CVData, rejecting unknown fields
+// so that typos in hand-edited exports are reported instead of dropped.
+func decodeCVData(data []byte) (CVData, []fieldError) {
+	var cvData CVData
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cvData); err != nil {
+		return cvData, []fieldError{jsonFieldError(err)}
+	}
+	if dec.More() {
+		return cvData, []fieldError{{Message: "unexpected data after JSON document"}}
+	}
+	return cvData, nil
+}
+
+func jsonFieldError(err error) fieldError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fieldError{Message: fmt.Sprintf("invalid JSON at offset %d: %v", syntaxErr.Offset, syntaxErr)}
+	case errors.As(err, &typeErr):
+		return fieldError{Field: typeErr.Field, Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return fieldError{Field: field, Message: "unknown field"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fieldError{Message: "unexpected end of JSON input"}
+	default:
+		return fieldError{Message: err.Error()}
+	}
+}
+
+func validateCVData(cvData CVData) []fieldError {
+	var problems []fieldError
+	if strings.TrimSpace(cvData.Name) == "" {
+		problems = append(problems, fieldError{Field: "name", Message: "is required"})
+	}
+	if email := strings.TrimSpace(cvData.Email); email != "" {
+		if _, err := mail.ParseAddress(email); err != nil {
+			problems = append(problems, fieldError{Field: "email", Message: "is not a valid email address"})
+		}
+	}
+	for i, exp := range cvData.Experience {
+		if strings.TrimSpace(exp.Title) == "" {
+			problems = append(problems, fieldError{Field: fmt.Sprintf("experience[%d].title", i), Message: "is required"})
+		}
+		if strings.TrimSpace(exp.Company) == "" {
+			problems = append(problems, fieldError{Field: fmt.Sprintf("experience[%d].company", i), Message: "is required"})
+		}
+		switch {
+		case exp.Start != nil && exp.Start.Present:
+			problems = append(problems, fieldError{Field: fmt.Sprintf("experience[%d].start", i), Message: "cannot be \"present\""})
+		case exp.Start == nil && exp.End != nil:
+			problems = append(problems, fieldError{Field: fmt.Sprintf("experience[%d].end", i), Message: "requires a start date"})
+		case exp.Start != nil && exp.End != nil && !exp.End.Present && exp.End.monthIndex(true, time.Time{}) < exp.Start.monthIndex(false, time.Time{}):
+			problems = append(problems, fieldError{Field: fmt.Sprintf("experience[%d].end", i), Message: "is before the start date"})
+		}
+	}
+	for i, edu := range cvData.Education {
+		if strings.TrimSpace(edu.Institution) == "" {
+			problems = append(problems, fieldError{Field: fmt.Sprintf("education[%d].institution", i), Message: "is required"})
+		}
+	}
+	for i, cert := range cvData.Certifications {
+		if strings.TrimSpace(cert.Name) == "" {
+			problems = append(problems, fieldError{Field: fmt.Sprintf("certifications[%d].name", i), Message: "is required"})
+		}
+		dates := []struct{ field, value string }{{"issued", cert.Issued}, {"expires", cert.Expires}}
+		for _, date := range dates {
+			if value := strings.TrimSpace(date.value); value != "" {
+				if _, ok := parseCertificationDate(value); !ok {
+					problems = append(problems, fieldError{Field: fmt.Sprintf("certifications[%d].%s", i, date.field), Message: "must be formatted as YYYY, YYYY-MM or YYYY-MM-DD"})
+				}
+			}
+		}
+		if u := strings.TrimSpace(cert.URL); u != "" {
+			if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+				problems = append(problems, fieldError{Field: fmt.Sprintf("certifications[%d].url", i), Message: "must be an http or https URL"})
+			}
+		}
+	}
+	return problems
+}
+
+// normalizeCVData trims whitespace, drops blank list entries and replaces nil
+// slices with empty ones so the frontend always receives arrays.
+func normalizeCVData(cvData *CVData) {
+	cvData.Name = strings.TrimSpace(cvData.Name)
+	cvData.Address = strings.TrimSpace(cvData.Address)
+	cvData.Phone1 = strings.TrimSpace(cvData.Phone1)
+	cvData.Phone2 = strings.TrimSpace(cvData.Phone2)
+	cvData.Email = strings.TrimSpace(cvData.Email)
+	cvData.Statement = strings.TrimSpace(strings.ReplaceAll(cvData.Statement, "\r\n", "\n"))
+	cvData.Skills = compactTagged(cvData.Skills)
+	cvData.Interests = compactStrings(cvData.Interests)
+	if cvData.Experience == nil {
+		cvData.Experience = []Experience{}
+	}
+	for i := range cvData.Experience {
+		exp := &cvData.Experience[i]
+		exp.Title = strings.TrimSpace(exp.Title)
+		exp.Company = strings.TrimSpace(exp.Company)
+		exp.Period = strings.TrimSpace(exp.Period)
+		exp.resolvePeriod()
+		if exp.Period == "" && exp.Start != nil {
+			exp.Period = exp.DateRange(dateFormats["short"])
+		}
+		exp.Duties = compactTagged(exp.Duties)
+		exp.Tags = normalizeTags(exp.Tags)
+	}
+	if cvData.Education == nil {
+		cvData.Education = []Education{}
+	}
+	for i := range cvData.Education {
+		edu := &cvData.Education[i]
+		edu.Institution = strings.TrimSpace(edu.Institution)
+		edu.Qualification = strings.TrimSpace(edu.Qualification)
+		edu.Field = strings.TrimSpace(edu.Field)
+		edu.Start = strings.TrimSpace(edu.Start)
+		edu.End = strings.TrimSpace(edu.End)
+		edu.Grade = strings.TrimSpace(edu.Grade)
+		edu.Notes = strings.TrimSpace(strings.ReplaceAll(edu.Notes, "\r\n", "\n"))
+	}
+	if cvData.Certifications == nil {
+		cvData.Certifications = []Certification{}
+	}
+	for i := range cvData.Certifications {
+		cert := &cvData.Certifications[i]
+		cert.Name = strings.TrimSpace(cert.Name)
+		cert.Issuer = strings.TrimSpace(cert.Issuer)
+		cert.CredentialID = strings.TrimSpace(cert.CredentialID)
+		cert.Issued = strings.TrimSpace(cert.Issued)
+		cert.Expires = strings.TrimSpace(cert.Expires)
+		cert.URL = strings.TrimSpace(cert.URL)
+	}
+}
+
+func compactStrings(items []string) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type jobStatus string
+
+const (
+	jobQueued    jobStatus = "queued"
+	jobRendering jobStatus = "rendering"
+	jobDone      jobStatus = "done"
+	jobFailed    jobStatus = "failed"
+)
+
+// pdfJob is the client-visible state of an asynchronous PDF render.
+type pdfJob struct {
+	ID           string    `json:"id"`
+	Status       jobStatus `json:"status"`
+	Attempts     int       `json:"attempts"`
+	Error        string    `json:"error,omitempty"`
+	DownloadLink string    `json:"download_link,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// ownerID is the user who submitted the job; nobody else can see it.
+	ownerID string
+}
+
+func (j pdfJob) finished() bool {
+	return j.Status == jobDone || j.Status == jobFailed
+}
+
+type queuedJob struct {
+	id      string
+	ownerID string
+	html    []byte
+}
+
+var errQueueFull = errors.New("job queue is full")
+
+// jobQueue renders PDFs on a fixed pool of workers so that bursts of requests
+// wait in a bounded queue instead of all hitting the renderer at once. Failed
+// renders are retried with exponential backoff before the job is failed.
+type jobQueue struct {
+	renderer    pdfRenderer
+	maxAttempts int
+	pending     chan queuedJob
+
+	mu          sync.Mutex
+	jobs        map[string]*pdfJob
+	subscribers map[string][]chan pdfJob
+}
+
+// jobs is the queue configured at startup.
+var jobs *jobQueue
+
+func newJobQueue(renderer pdfRenderer, workers, queueSize, maxAttempts int) *jobQueue {
+	q := &jobQueue{
+		renderer:    renderer,
+		maxAttempts: maxAttempts,
+		pending:     make(chan queuedJob, queueSize),
+		jobs:        make(map[string]*pdfJob),
+		subscribers: make(map[string][]chan pdfJob),
+	}
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	go q.pruneExpired()
+	return q
+}
+
+// Submit queues html for rendering on behalf of ownerID, failing with
+// errQueueFull rather than blocking when the queue is at capacity.
+func (q *jobQueue) Submit(ownerID string, html []byte) (pdfJob, error) {
+	now := time.Now()
+	job := &pdfJob{ID: uuid.New().String(), Status: jobQueued, CreatedAt: now, UpdatedAt: now, ownerID: ownerID}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case q.pending <- queuedJob{id: job.ID, ownerID: ownerID, html: html}:
+	default:
+		return pdfJob{}, errQueueFull
+	}
+	q.jobs[job.ID] = job
+	return *job, nil
+}
+
+func (q *jobQueue) Get(id string) (pdfJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return pdfJob{}, false
+	}
+	return *job, true
+}
+
+// Subscribe returns the job's current state and a channel that receives the
+// latest state after every change. Call the returned function to stop
+// receiving updates.
+func (q *jobQueue) Subscribe(id string) (pdfJob, <-chan pdfJob, func(), bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return pdfJob{}, nil, nil, false
+	}
+
+	updates := make(chan pdfJob, 1)
+	q.subscribers[id] = append(q.subscribers[id], updates)
+	unsubscribe := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		subs := q.subscribers[id]
+		for i, ch := range subs {
+			if ch == updates {
+				q.subscribers[id] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(q.subscribers[id]) == 0 {
+			delete(q.subscribers, id)
+		}
+	}
+	return *job, updates, unsubscribe, true
+}
+
+func (q *jobQueue) update(id string, apply func(*pdfJob)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return
+	}
+	apply(job)
+	job.UpdatedAt = time.Now()
+
+	// Subscribers only care about the latest state, so a pending update that
+	// has not been read yet is replaced rather than blocking the worker.
+	for _, ch := range q.subscribers[id] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- *job
+	}
+}
+
+func (q *jobQueue) work() {
+	for item := range q.pending {
+		q.process(item)
+	}
+}
+
+func (q *jobQueue) process(item queuedJob) {
+	// Like other downloads, the PDF goes in the owner's directory so only
+	// they can fetch it.
+	path := filepath.Join(tempDir, item.ownerID, fmt.Sprintf("%s.pdf", item.id))
+
+	for attempt := 1; attempt <= q.maxAttempts; attempt++ {
+		q.update(item.id, func(j *pdfJob) {
+			j.Status = jobRendering
+			j.Attempts = attempt
+		})
+
+		err := q.render(item.html, path)
+		if err == nil {
+			q.update(item.id, func(j *pdfJob) {
+				j.Status = jobDone
+				j.Error = ""
+				j.DownloadLink = fmt.Sprintf("/download-pdf/%s.pdf", item.id)
+			})
+			return
+		}
+		log.Printf("Error rendering job %s with %s (attempt %d/%d): %v",
+			item.id, q.renderer.Name(), attempt, q.maxAttempts, err)
+
+		if attempt < q.maxAttempts {
+			q.update(item.id, func(j *pdfJob) {
+				j.Status = jobQueued
+				j.Error = "Failed to generate PDF, retrying"
+			})
+			time.Sleep(time.Second << (attempt - 1))
+		}
+	}
+
+	q.update(item.id, func(j *pdfJob) {
+		j.Status = jobFailed
+		j.Error = "Failed to generate PDF"
+	})
+}
+
+func (q *jobQueue) render(html []byte, path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), renderTimeout)
+	defer cancel()
+
+	pdf, err := q.renderer.Render(ctx, bytes.NewReader(html))
+	if err != nil {
+		return err
+	}
+	defer pdf.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := writeFileFrom(path, pdf); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// pruneExpired forgets finished jobs once their PDFs have been cleaned up
+// from the temp directory.
+func (q *jobQueue) pruneExpired() {
+	for {
+		time.Sleep(5 * time.Minute)
+
+		q.mu.Lock()
+		for id, job := range q.jobs {
+			if job.finished() && time.Since(job.UpdatedAt) > expirationTime {
+				delete(q.jobs, id)
+			}
+		}
+		q.mu.Unlock()
+	}
+}
+
+func submitJob(c *gin.Context) {
+	var cvData CVData
+	if !bindCV(c, &cvData) {
+		return
+	}
+
+	html, err := renderCVHTML(c.Request.Context(), cvData, parseRenderOptions(c))
+	if errors.Is(err, errUnknownTemplate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown template"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate HTML"})
+		return
+	}
+
+	job, err := jobs.Submit(currentUser(c).ID, html.Bytes())
+	if errors.Is(err, errQueueFull) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many PDFs queued, please try again later"})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/jobs/%s", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+func getJob(c *gin.Context) {
+	job, ok := jobs.Get(c.Param("id"))
+	if !ok || job.ownerID != currentUser(c).ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// jobEvents streams the job's state as server-sent "status" events until it
+// is done or failed.
+func jobEvents(c *gin.Context) {
+	job, updates, unsubscribe, ok := jobs.Subscribe(c.Param("id"))
+	if ok && job.ownerID != currentUser(c).ID {
+		unsubscribe()
+		ok = false
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	defer unsubscribe()
+
+	c.SSEvent("status", job)
+	c.Writer.Flush()
+	if job.finished() {
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case job := <-updates:
+			c.SSEvent("status", job)
+			return !job.finished()
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
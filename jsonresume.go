@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const jsonResumeSchema = "https://raw.githubusercontent.com/jsonresume/resume-schema/v1.0.0/schema.json"
+
+// JSONResume is the subset of the JSON Resume v1 schema (jsonresume.org) that
+// CVData can be mapped onto. Sections CVData has no equivalent for are kept
+// so documents survive decoding, but they are not carried into CVData.
+type JSONResume struct {
+	Schema       string                  `json:"$schema,omitempty"`
+	Basics       JSONResumeBasics        `json:"basics"`
+	Work         []JSONResumeWork        `json:"work,omitempty"`
+	Volunteer    []json.RawMessage       `json:"volunteer,omitempty"`
+	Education    []JSONResumeEducation   `json:"education,omitempty"`
+	Awards       []json.RawMessage       `json:"awards,omitempty"`
+	Certificates []JSONResumeCertificate `json:"certificates,omitempty"`
+	Publications []json.RawMessage       `json:"publications,omitempty"`
+	Skills       []JSONResumeSkill       `json:"skills,omitempty"`
+	Languages    []json.RawMessage       `json:"languages,omitempty"`
+	Interests    []JSONResumeInterest    `json:"interests,omitempty"`
+	References   []json.RawMessage       `json:"references,omitempty"`
+	Projects     []json.RawMessage       `json:"projects,omitempty"`
+	Meta         *JSONResumeMeta         `json:"meta,omitempty"`
+}
+
+type JSONResumeBasics struct {
+	Name     string              `json:"name"`
+	Label    string              `json:"label,omitempty"`
+	Image    string              `json:"image,omitempty"`
+	Email    string              `json:"email,omitempty"`
+	Phone    string              `json:"phone,omitempty"`
+	URL      string              `json:"url,omitempty"`
+	Summary  string              `json:"summary,omitempty"`
+	Location *JSONResumeLocation `json:"location,omitempty"`
+	Profiles []json.RawMessage   `json:"profiles,omitempty"`
+}
+
+type JSONResumeLocation struct {
+	Address     string `json:"address,omitempty"`
+	PostalCode  string `json:"postalCode,omitempty"`
+	City        string `json:"city,omitempty"`
+	CountryCode string `json:"countryCode,omitempty"`
+	Region      string `json:"region,omitempty"`
+}
+
+type JSONResumeWork struct {
+	Name       string   `json:"name,omitempty"`
+	Position   string   `json:"position,omitempty"`
+	URL        string   `json:"url,omitempty"`
+	StartDate  string   `json:"startDate,omitempty"`
+	EndDate    string   `json:"endDate,omitempty"`
+	Summary    string   `json:"summary,omitempty"`
+	Highlights []string `json:"highlights,omitempty"`
+}
+
+type JSONResumeEducation struct {
+	Institution string   `json:"institution,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Area        string   `json:"area,omitempty"`
+	StudyType   string   `json:"studyType,omitempty"`
+	StartDate   string   `json:"startDate,omitempty"`
+	EndDate     string   `json:"endDate,omitempty"`
+	Score       string   `json:"score,omitempty"`
+	Courses     []string `json:"courses,omitempty"`
+}
+
+// JSONResumeCertificate is the schema's certificate. The schema has no
+// credential ID or expiry date, so those are carried as extension fields,
+// which the schema allows, to make the mapping lossless.
+type JSONResumeCertificate struct {
+	Name         string `json:"name,omitempty"`
+	Date         string `json:"date,omitempty"`
+	URL          string `json:"url,omitempty"`
+	Issuer       string `json:"issuer,omitempty"`
+	CredentialID string `json:"credentialId,omitempty"`
+	Expires      string `json:"expires,omitempty"`
+}
+
+type JSONResumeSkill struct {
+	Name     string   `json:"name,omitempty"`
+	Level    string   `json:"level,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+type JSONResumeInterest struct {
+	Name     string   `json:"name,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+// JSONResumeMeta carries the schema's meta block. The schema has a single
+// phone number, so the secondary one is kept here to make the mapping lossless.
+type JSONResumeMeta struct {
+	Canonical    string `json:"canonical,omitempty"`
+	Version      string `json:"version,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Phone2       string `json:"phone2,omitempty"`
+}
+
+func toJSONResume(cvData CVData) JSONResume {
+	resume := JSONResume{
+		Schema: jsonResumeSchema,
+		Basics: JSONResumeBasics{
+			Name:    cvData.Name,
+			Email:   cvData.Email,
+			Phone:   cvData.Phone1,
+			Summary: cvData.Statement,
+		},
+	}
+	if cvData.Address != "" {
+		resume.Basics.Location = &JSONResumeLocation{Address: cvData.Address}
+	}
+	if cvData.Phone2 != "" {
+		resume.Meta = &JSONResumeMeta{Phone2: cvData.Phone2}
+	}
+
+	for _, exp := range cvData.Experience {
+		work := JSONResumeWork{
+			Name:       exp.Company,
+			Position:   exp.Title,
+			Highlights: texts(exp.Duties),
+		}
+		exp.resolvePeriod()
+		if exp.Start != nil {
+			start, _ := exp.Start.MarshalText()
+			work.StartDate = string(start)
+			if exp.End != nil && !exp.End.Present {
+				end, _ := exp.End.MarshalText()
+				work.EndDate = string(end)
+			}
+		} else {
+			work.Summary = exp.Period
+		}
+		resume.Work = append(resume.Work, work)
+	}
+
+	for _, edu := range cvData.Education {
+		education := JSONResumeEducation{
+			Institution: edu.Institution,
+			Area:        edu.Field,
+			StudyType:   edu.Qualification,
+			StartDate:   edu.Start,
+			EndDate:     edu.End,
+			Score:       edu.Grade,
+		}
+		if edu.Notes != "" {
+			education.Courses = strings.Split(edu.Notes, "\n")
+		}
+		resume.Education = append(resume.Education, education)
+	}
+
+	for _, cert := range cvData.Certifications {
+		resume.Certificates = append(resume.Certificates, JSONResumeCertificate{
+			Name:         cert.Name,
+			Date:         cert.Issued,
+			URL:          cert.URL,
+			Issuer:       cert.Issuer,
+			CredentialID: cert.CredentialID,
+			Expires:      cert.Expires,
+		})
+	}
+
+	for _, skill := range cvData.Skills {
+		resume.Skills = append(resume.Skills, JSONResumeSkill{Name: skill.Text})
+	}
+	for _, interest := range cvData.Interests {
+		resume.Interests = append(resume.Interests, JSONResumeInterest{Name: interest})
+	}
+	return resume
+}
+
+func fromJSONResume(resume JSONResume) CVData {
+	cvData := CVData{
+		Name:      resume.Basics.Name,
+		Email:     resume.Basics.Email,
+		Phone1:    resume.Basics.Phone,
+		Statement: resume.Basics.Summary,
+	}
+	if loc := resume.Basics.Location; loc != nil {
+		cvData.Address = joinNonEmpty(", ", loc.Address, loc.City, loc.Region, loc.PostalCode, loc.CountryCode)
+	}
+	if resume.Meta != nil {
+		cvData.Phone2 = resume.Meta.Phone2
+	}
+
+	for _, work := range resume.Work {
+		exp := Experience{
+			Title:   work.Position,
+			Company: work.Name,
+			Duties:  untagged(work.Highlights),
+		}
+		var start, end YearMonth
+		if err := start.UnmarshalText([]byte(work.StartDate)); err == nil && !start.Present {
+			exp.Start = &start
+			exp.End = &YearMonth{Present: true}
+			if end.UnmarshalText([]byte(work.EndDate)) == nil {
+				exp.End = &end
+			}
+		} else {
+			exp.Period = work.Summary
+		}
+		cvData.Experience = append(cvData.Experience, exp)
+	}
+
+	for _, education := range resume.Education {
+		cvData.Education = append(cvData.Education, Education{
+			Institution:   education.Institution,
+			Qualification: education.StudyType,
+			Field:         education.Area,
+			Start:         education.StartDate,
+			End:           education.EndDate,
+			Grade:         education.Score,
+			Notes:         strings.Join(education.Courses, "\n"),
+		})
+	}
+
+	for _, cert := range resume.Certificates {
+		cvData.Certifications = append(cvData.Certifications, Certification{
+			Name:         cert.Name,
+			Issuer:       cert.Issuer,
+			CredentialID: cert.CredentialID,
+			Issued:       cert.Date,
+			Expires:      cert.Expires,
+			URL:          cert.URL,
+		})
+	}
+
+	for _, skill := range resume.Skills {
+		if len(skill.Keywords) > 0 {
+			cvData.Skills = append(cvData.Skills, TaggedText{Text: skill.Name + " (" + strings.Join(skill.Keywords, ", ") + ")"})
+		} else {
+			cvData.Skills = append(cvData.Skills, TaggedText{Text: skill.Name})
+		}
+	}
+	for _, interest := range resume.Interests {
+		cvData.Interests = append(cvData.Interests, interest.Name)
+	}
+	return cvData
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+func exportJSONResume(c *gin.Context) {
+	var cvData CVData
+	if !bindCV(c, &cvData) {
+		return
+	}
+
+	jsonData, err := json.MarshalIndent(toJSONResume(cvData), "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate JSON Resume"})
+		return
+	}
+
+	filename := "resume_" + uuid.New().String() + ".json"
+	path, err := newDownloadPath(c, filename)
+	if err == nil {
+		err = os.WriteFile(path, jsonData, 0644)
+	}
+	if err != nil {
+		log.Printf("Error saving JSON Resume: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save JSON Resume file"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"download_link": "/download-jsonresume/" + filename,
+	})
+}
+
+func importJSONResume(c *gin.Context) {
+	body, err := readImportBody(c)
+	if err != nil {
+		log.Printf("Error reading import body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var resume JSONResume
+	if err := json.Unmarshal(body, &resume); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Invalid JSON Resume document",
+			"problems": []fieldError{jsonFieldError(err)},
+		})
+		return
+	}
+
+	cvData := fromJSONResume(resume)
+	normalizeCVData(&cvData)
+	if problems := validateCVData(cvData); len(problems) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Invalid CV data",
+			"problems": problems,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cv": cvData,
+	})
+}
+
+func downloadJSONResume(c *gin.Context) {
+	path := downloadPath(c, c.Param("filename"))
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "JSON Resume file not found"})
+		return
+	}
+
+	c.FileAttachment(path, "resume.json")
+}
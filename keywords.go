@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenize splits text into lower-case words. Characters common in technology
+// names are kept inside a word, so "C++", "node.js" and "CI/CD" survive as
+// single tokens.
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("+#./-", r)
+	})
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		// Sentence punctuation and hyphens at the edges are not part of the word.
+		field = strings.Trim(field, "./-")
+		if field != "" && strings.IndexFunc(field, unicode.IsLetter) >= 0 {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
+
+// stemSuffixes are the derivational endings removed by stem, longest first.
+var stemSuffixes = []struct{ suffix, replacement string }{
+	{"ational", "ate"},
+	{"ization", "ize"},
+	{"isation", "ise"},
+	{"fulness", "ful"},
+	{"iveness", "ive"},
+	{"ousness", "ous"},
+	{"ation", "ate"},
+	{"ment", ""},
+	{"ness", ""},
+	{"ity", ""},
+	{"ize", ""},
+	{"ise", ""},
+	{"ly", ""},
+	{"er", ""},
+	{"or", ""},
+}
+
+// stem is a light suffix-stripping stemmer in the spirit of Porter's: it
+// conflates inflections such as "develop", "developer", "developing" and
+// "development" without aiming to produce dictionary words. Tokens that
+// contain anything but letters are returned unchanged.
+func stem(word string) string {
+	if len(word) <= 3 || strings.IndexFunc(word, func(r rune) bool { return r < 'a' || r > 'z' }) >= 0 {
+		return word
+	}
+
+	strip := func(suffix, replacement string) bool {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= 3 {
+			word = word[:len(word)-len(suffix)] + replacement
+			return true
+		}
+		return false
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		// "process", "status" and "analysis" are not plurals.
+	case strip("ies", "y"):
+	case strip("s", ""):
+	}
+	if !strip("ing", "") {
+		strip("ed", "")
+	}
+	for _, s := range stemSuffixes {
+		if strip(s.suffix, s.replacement) {
+			break
+		}
+	}
+	for _, s := range []string{"ize", "ise", "iz", "e"} {
+		if strip(s, "") {
+			break
+		}
+	}
+
+	// Undouble final consonants left by "running" or "planned", as Porter
+	// does, except for l, s and z.
+	if n := len(word); n > 3 && word[n-1] == word[n-2] && !strings.ContainsRune("aeioulsz", rune(word[n-1])) {
+		word = word[:n-1]
+	}
+	return word
+}
+
+func isStopWord(word string) bool {
+	_, ok := stopWords[word]
+	return ok
+}
+
+// stopWords are common English words plus the filler that appears in most
+// job descriptions, none of which make useful keywords.
+var stopWords = toSet(strings.Fields(`
+	a about above after again against all also am an and any are as at be
+	because been before being below between both but by can could did do does
+	doing down during each etc few for from further had has have having he her
+	here hers herself him himself his how i if in into is it its itself just
+	let me more most my myself no nor not now of off on once only or other our
+	ours ourselves out over own per same she should so some such than that the
+	their theirs them themselves then there these they this those through to
+	too under until up upon us very via was we were what when where which while
+	who whom why will with within without would you your yours yourself
+	yourselves e.g i.e
+
+	ability able across candidate candidates company day days duties excellent
+	experience experienced good great ideal including join key looking must
+	new plus preferred responsibilities responsible role skills strong team
+	well work working year years
+`))
+
+func toSet(items []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("Experience with C++, Node.js and CI/CD. Self-motivated -- 5 years; e.g. Go!")
+	want := []string{"experience", "with", "c++", "node.js", "and", "ci/cd", "self-motivated", "years", "e.g", "go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize = %q, want %q", got, want)
+	}
+}
+
+func TestStem(t *testing.T) {
+	groups := [][]string{
+		{"develop", "developer", "developing", "development", "developed"},
+		{"manager", "managing", "management"},
+		{"optimization", "optimize"},
+		{"run", "running"},
+		{"database", "databases"},
+	}
+	for _, words := range groups {
+		want := stem(words[0])
+		for _, word := range words[1:] {
+			if got := stem(word); got != want {
+				t.Errorf("stem(%q) = %q, want %q like %q", word, got, want, words[0])
+			}
+		}
+	}
+
+	for _, word := range []string{"process", "status", "analysis", "api", "c++", "node.js"} {
+		if got := stem(word); got != word {
+			t.Errorf("stem(%q) = %q, want it unchanged", word, got)
+		}
+	}
+}
+
+func TestAnalyzeKeywords(t *testing.T) {
+	cvData := CVData{
+		Statement: "Developer building APIs",
+		Skills:    untagged([]string{"Go", "Kubernetes"}),
+	}
+	report := analyzeKeywords(cvData, "Go developer. Go, Kubernetes and Terraform. Terraform required.")
+
+	if report.MatchPercentage != 60 {
+		t.Errorf("match percentage = %d, want 60", report.MatchPercentage)
+	}
+	if want := []string{"terraform", "required"}; !reflect.DeepEqual(report.Missing, want) {
+		t.Errorf("missing = %q, want %q", report.Missing, want)
+	}
+	sections := map[string][]string{}
+	for _, match := range report.Keywords {
+		sections[match.Keyword] = match.Sections
+	}
+	if want := []string{"skills"}; !reflect.DeepEqual(sections["go"], want) {
+		t.Errorf("go found in %q, want %q", sections["go"], want)
+	}
+	if want := []string{"statement"}; !reflect.DeepEqual(sections["developer"], want) {
+		t.Errorf("developer found in %q, want %q", sections["developer"], want)
+	}
+	if report.Keywords[0].Keyword != "go" || report.Keywords[0].JDCount != 2 {
+		t.Errorf("first keyword = %+v, want go twice", report.Keywords[0])
+	}
+}
+
+func TestAnalyzeKeywordsOverused(t *testing.T) {
+	cvData := CVData{
+		Statement: "Managed teams.",
+		Experience: []Experience{{Duties: untagged([]string{
+			"Managed releases", "Managed hiring", "Managing budgets",
+		})}},
+		// Skills are not prose, so repeating them is not overuse.
+		Skills: untagged([]string{"Go", "Go", "Go", "Go"}),
+	}
+	report := analyzeKeywords(cvData, "Go")
+	if want := []wordCount{{Word: "managed", Count: 4}}; !reflect.DeepEqual(report.Overused, want) {
+		t.Errorf("overused = %+v, want %+v", report.Overused, want)
+	}
+}
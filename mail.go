@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// publicURL is where users reach the app, used for links in emails. It is
+// configured rather than taken from the request so a forged Host header
+// cannot redirect reset links.
+var publicURL = strings.TrimRight(getEnv("PUBLIC_URL", "http://localhost"), "/")
+
+// smtpMailer sends email through the server configured with SMTP_HOST,
+// SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM. Without SMTP_HOST
+// nothing is sent and only the recipient and subject are logged. Bodies hold
+// live reset links, so they are logged only when MAIL_LOG_BODY=true, for
+// local development.
+type smtpMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+	logBody  bool
+}
+
+var mailer = smtpMailer{
+	host:     getEnv("SMTP_HOST", ""),
+	port:     getEnv("SMTP_PORT", "587"),
+	username: getEnv("SMTP_USERNAME", ""),
+	password: getEnv("SMTP_PASSWORD", ""),
+	from:     getEnv("SMTP_FROM", "cv-builder@localhost"),
+	logBody:  getEnv("MAIL_LOG_BODY", "false") == "true",
+}
+
+// Send delivers a plain-text message. to and subject must not contain line
+// breaks.
+func (m smtpMailer) Send(to, subject, body string) error {
+	if m.host == "" {
+		if m.logBody {
+			log.Printf("SMTP_HOST is not set, so this email to %s was not sent:\nSubject: %s\n\n%s", to, subject, body)
+		} else {
+			log.Printf("SMTP_HOST is not set, so the email %q to %s was not sent", subject, to)
+		}
+		return nil
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	message := "From: " + m.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + strings.ReplaceAll(body, "\n", "\r\n")
+	return smtp.SendMail(net.JoinHostPort(m.host, m.port), auth, m.from, []string{to}, []byte(message))
+}
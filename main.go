@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/static"
@@ -11,53 +18,196 @@ import (
 )
 
 type CVData struct {
-	Name       string   `json:"name"`
-	Address    string   `json:"address"`
-	Phone1     string   `json:"phone1"`
-	Phone2     string   `json:"phone2"`
-	Email      string   `json:"email"`
-	Statement  string   `json:"statement"`
-	Skills     []string `json:"skills"`
-	Experience []struct {
-		Title   string   `json:"title"`
-		Company string   `json:"company"`
-		Period  string   `json:"period"`
-		Duties  []string `json:"duties"`
-	} `json:"experience"`
-	Interests []string `json:"interests"`
+	Name           string          `json:"name" yaml:"name" toml:"name"`
+	Address        string          `json:"address" yaml:"address" toml:"address"`
+	Phone1         string          `json:"phone1" yaml:"phone1" toml:"phone1"`
+	Phone2         string          `json:"phone2" yaml:"phone2" toml:"phone2"`
+	Email          string          `json:"email" yaml:"email" toml:"email"`
+	Statement      string          `json:"statement" yaml:"statement" toml:"statement,multiline"`
+	Skills         []TaggedText    `json:"skills" yaml:"skills" toml:"skills,inline"`
+	Experience     []Experience    `json:"experience" yaml:"experience" toml:"experience"`
+	Education      []Education     `json:"education" yaml:"education" toml:"education"`
+	Certifications []Certification `json:"certifications" yaml:"certifications" toml:"certifications"`
+	Interests      []string        `json:"interests" yaml:"interests" toml:"interests"`
+}
+
+type Experience struct {
+	Title   string     `json:"title" yaml:"title" toml:"title"`
+	Company string     `json:"company" yaml:"company" toml:"company"`
+	Start   *YearMonth `json:"start,omitempty" yaml:"start,omitempty" toml:"start,omitempty"`
+	End     *YearMonth `json:"end,omitempty" yaml:"end,omitempty" toml:"end,omitempty"`
+	// Period is the legacy free-text date range, still accepted as input and
+	// parsed into Start and End where possible.
+	Period string       `json:"period" yaml:"period" toml:"period"`
+	Duties []TaggedText `json:"duties" yaml:"duties" toml:"duties,inline"`
+	Tags   []string     `json:"tags,omitempty" yaml:"tags,omitempty" toml:"tags,omitempty"`
+}
+
+type Education struct {
+	Institution   string `json:"institution" yaml:"institution" toml:"institution"`
+	Qualification string `json:"qualification" yaml:"qualification" toml:"qualification"`
+	Field         string `json:"field" yaml:"field" toml:"field"`
+	Start         string `json:"start" yaml:"start" toml:"start"`
+	End           string `json:"end" yaml:"end" toml:"end"`
+	Grade         string `json:"grade" yaml:"grade" toml:"grade"`
+	Notes         string `json:"notes" yaml:"notes" toml:"notes,multiline"`
+}
+
+func (e Education) Period(layout string) string {
+	return joinNonEmpty(" - ", formatDate(e.Start, layout), formatDate(e.End, layout))
+}
+
+type Certification struct {
+	Name         string `json:"name" yaml:"name" toml:"name"`
+	Issuer       string `json:"issuer" yaml:"issuer" toml:"issuer"`
+	CredentialID string `json:"credential_id" yaml:"credential_id" toml:"credential_id"`
+	Issued       string `json:"issued" yaml:"issued" toml:"issued"`
+	Expires      string `json:"expires" yaml:"expires" toml:"expires"`
+	URL          string `json:"url" yaml:"url" toml:"url"`
 }
 
 func main() {
 	ginMode := getEnv("GIN_MODE", "debug")
 	gin.SetMode(ginMode)
 	r := gin.Default()
+	// Client addresses are used to limit password attempts, so forwarded
+	// addresses, like the forwarded protocol in isHTTPS, are only believed
+	// from proxies listed in TRUSTED_PROXIES.
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	var err error
+	if renderer, err = newPDFRenderer(); err != nil {
+		log.Fatalf("Failed to configure PDF renderer: %v", err)
+	}
+	log.Printf("Using %s PDF renderer", renderer.Name())
+
+	if store, err = newStore(); err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	adoptLegacyCVs(context.Background())
+
+	jobs = newJobQueue(renderer, getEnvInt("JOB_WORKERS", 2), getEnvInt("JOB_QUEUE_SIZE", 100), getEnvInt("JOB_MAX_ATTEMPTS", 3))
 
 	config := cors.DefaultConfig()
 	allowedOrigins := strings.Split(getEnv("ALLOWED_ORIGINS", "http://localhost"), ",")
 	config.AllowOrigins = allowedOrigins
-	config.AllowMethods = []string{"GET", "POST", "OPTIONS"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
 	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept"}
+	config.AllowCredentials = true
 	r.Use(cors.New(config))
 
 	r.Use(static.Serve("/", static.LocalFile("./dist", false)))
 
-	api := r.Group("/api")
+	auth := r.Group("/api/auth")
 	{
+		auth.POST("/register", register)
+		auth.POST("/login", login)
+		auth.POST("/logout", logout)
+		auth.POST("/password-reset", requestPasswordReset)
+		auth.POST("/password-reset/confirm", resetPassword)
+		auth.GET("/me", requireUser, getCurrentUser)
+	}
+
+	// The rest of the API and the downloads it creates need a signed-in
+	// user, and saved CVs are only reachable by their owner.
+	api := r.Group("/api", requireUser)
+	{
+		api.GET("/templates", listTemplates)
+		api.POST("/analyze", analyzeCV)
 		api.POST("/generate-pdf", generatePDF)
 		api.POST("/export-json", exportJSON)
+		api.POST("/import-json", importJSON)
+		api.POST("/export-jsonresume", exportJSONResume)
+		api.POST("/import-jsonresume", importJSONResume)
+		api.POST("/export-text", exportText)
+		api.POST("/export-docx", exportDOCX)
+		api.POST("/export-markdown", exportMarkdown)
+		api.POST("/import-markdown", importMarkdown)
+		api.POST("/export-yaml", exportYAML)
+		api.POST("/export-toml", exportTOML)
+		api.POST("/export-latex", exportLaTeX)
+		api.POST("/export-odt", exportODT)
+		api.POST("/export-html", exportHTML)
+		api.POST("/jobs", submitJob)
+		api.GET("/jobs/:id", getJob)
+		api.GET("/jobs/:id/events", jobEvents)
+		api.GET("/cvs", listCVs)
+		api.POST("/cvs", createCV)
+	}
+	cv := api.Group("/cvs/:id", requireCVOwner)
+	{
+		cv.GET("", getCV)
+		cv.PUT("", updateCV)
+		cv.DELETE("", deleteCV)
+		cv.POST("/generate-pdf", generateStoredPDF)
+		cv.GET("/revisions", listRevisions)
+		cv.GET("/revisions/:rev", getRevision)
+		cv.POST("/revisions/:rev/restore", restoreRevision)
+		cv.GET("/diff", diffRevisions)
+		cv.GET("/variants", listVariants)
+		cv.POST("/variants", createVariant)
+		cv.GET("/variants/:variant", getVariant)
+		cv.PUT("/variants/:variant", updateVariant)
+		cv.DELETE("/variants/:variant", deleteVariant)
+		cv.POST("/variants/:variant/generate-pdf", generateVariantPDF)
+		cv.GET("/shares", listShares)
+		cv.POST("/shares", createShare)
+		cv.PATCH("/shares/:share", updateShare)
+		cv.DELETE("/shares/:share", revokeShare)
+		cv.GET("/shares/:share/analytics", getShareAnalytics)
 	}
 
-	r.GET("/download-pdf/:filename", downloadPDF)
-	r.GET("/download-json/:filename", downloadJSON)
+	downloads := r.Group("", requireUser)
+	{
+		downloads.GET("/download-pdf/:filename", downloadPDF)
+		downloads.GET("/download-json/:filename", downloadJSON)
+		downloads.GET("/download-jsonresume/:filename", downloadJSONResume)
+		downloads.GET(textDownload.path(), downloadText)
+		downloads.GET(docxDownload.path(), serveDownload(docxDownload))
+		downloads.GET(markdownDownload.path(), serveDownload(markdownDownload))
+		downloads.GET(yamlDownload.path(), serveDownload(yamlDownload))
+		downloads.GET(tomlDownload.path(), serveDownload(tomlDownload))
+		downloads.GET(latexDownload.path(), serveDownload(latexDownload))
+		downloads.GET(odtDownload.path(), serveDownload(odtDownload))
+		downloads.GET(htmlDownload.path(), serveDownload(htmlDownload))
+	}
+
+	shared := r.Group("/p", sharedPageHeaders)
+	{
+		shared.GET("/:token", viewShare)
+		shared.POST("/:token", unlockShare)
+		shared.GET("/:token/pdf", downloadSharePDF)
+	}
 
 	r.NoRoute(func(c *gin.Context) {
 		c.File("./dist/index.html")
 	})
 
 	port := getEnv("PORT", "80")
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	server := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// On SIGINT or SIGTERM, let requests in flight and reset emails finish
+	// before closing the store they may still be writing to.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+	pendingMail.Wait()
+	if err := store.Close(); err != nil {
+		log.Printf("Error closing storage: %v", err)
 	}
 }
 
@@ -67,3 +217,23 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("Ignoring invalid %s=%q", key, value)
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("Ignoring invalid %s=%q", key, value)
+	}
+	return fallback
+}
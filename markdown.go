@@ -0,0 +1,231 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/o4f6bgpac3/cv-builder/cvmarkdown"
+)
+
+var markdownDownload = downloadFormat{route: "markdown", extension: "md", label: "Markdown file"}
+
+// toMarkdown maps a CV onto the cvmarkdown convention, leaving out empty
+// sections.
+func toMarkdown(cvData CVData) *cvmarkdown.Document {
+	doc := &cvmarkdown.Document{
+		Name:        cvData.Name,
+		FrontMatter: markdownFields("address", cvData.Address, "phone1", cvData.Phone1, "phone2", cvData.Phone2, "email", cvData.Email),
+	}
+	add := func(section cvmarkdown.Section) {
+		if section.Text != "" || len(section.Items) > 0 || len(section.Entries) > 0 {
+			doc.Sections = append(doc.Sections, section)
+		}
+	}
+
+	add(cvmarkdown.Section{Name: cvmarkdown.Summary, Text: strings.TrimSpace(cvData.Statement)})
+	add(cvmarkdown.Section{Name: cvmarkdown.Skills, Items: markdownItems(cvData.Skills)})
+
+	experience := cvmarkdown.Section{Name: cvmarkdown.Experience}
+	for _, exp := range cvData.Experience {
+		fields := markdownFields("company", exp.Company, "start", yearMonthText(exp.Start), "end", yearMonthText(exp.End))
+		if exp.Start == nil {
+			fields = append(fields, markdownFields("period", exp.Period)...)
+		}
+		fields = append(fields, markdownFields("tags", strings.Join(exp.Tags, ", "))...)
+		experience.Entries = append(experience.Entries, cvmarkdown.Entry{
+			Heading: exp.Title,
+			Fields:  fields,
+			Items:   markdownItems(exp.Duties),
+		})
+	}
+	add(experience)
+
+	education := cvmarkdown.Section{Name: cvmarkdown.Education}
+	for _, edu := range cvData.Education {
+		education.Entries = append(education.Entries, cvmarkdown.Entry{
+			Heading: edu.Institution,
+			Fields:  markdownFields("qualification", edu.Qualification, "field", edu.Field, "start", edu.Start, "end", edu.End, "grade", edu.Grade),
+			Text:    strings.TrimSpace(edu.Notes),
+		})
+	}
+	add(education)
+
+	certifications := cvmarkdown.Section{Name: cvmarkdown.Certifications}
+	for _, cert := range cvData.Certifications {
+		certifications.Entries = append(certifications.Entries, cvmarkdown.Entry{
+			Heading: cert.Name,
+			Fields:  markdownFields("issuer", cert.Issuer, "credential_id", cert.CredentialID, "issued", cert.Issued, "expires", cert.Expires, "url", cert.URL),
+		})
+	}
+	add(certifications)
+
+	add(cvmarkdown.Section{Name: cvmarkdown.Interests, Items: markdownItems(untagged(cvData.Interests))})
+	return doc
+}
+
+// fromMarkdown reads a parsed document into a CV. Experience dates that do
+// not parse are reported as *cvmarkdown.Error so they carry their line.
+func fromMarkdown(doc *cvmarkdown.Document) (CVData, error) {
+	cvData := CVData{
+		Name:    doc.Name,
+		Address: doc.FrontMatter.Get("address"),
+		Phone1:  doc.FrontMatter.Get("phone1"),
+		Phone2:  doc.FrontMatter.Get("phone2"),
+		Email:   doc.FrontMatter.Get("email"),
+	}
+
+	for _, section := range doc.Sections {
+		switch section.Name {
+		case cvmarkdown.Summary:
+			cvData.Statement = section.Text
+		case cvmarkdown.Skills:
+			cvData.Skills = taggedItems(section.Items)
+		case cvmarkdown.Experience:
+			for _, entry := range section.Entries {
+				exp := Experience{
+					Title:   entry.Heading,
+					Company: entry.Fields.Get("company"),
+					Period:  entry.Fields.Get("period"),
+					Duties:  taggedItems(entry.Items),
+					Tags:    parseTagList(entry.Fields.Get("tags")),
+				}
+				var err error
+				if exp.Start, err = markdownYearMonth(entry.Fields, "start"); err != nil {
+					return CVData{}, err
+				}
+				if exp.End, err = markdownYearMonth(entry.Fields, "end"); err != nil {
+					return CVData{}, err
+				}
+				cvData.Experience = append(cvData.Experience, exp)
+			}
+		case cvmarkdown.Education:
+			for _, entry := range section.Entries {
+				cvData.Education = append(cvData.Education, Education{
+					Institution:   entry.Heading,
+					Qualification: entry.Fields.Get("qualification"),
+					Field:         entry.Fields.Get("field"),
+					Start:         entry.Fields.Get("start"),
+					End:           entry.Fields.Get("end"),
+					Grade:         entry.Fields.Get("grade"),
+					Notes:         entry.Text,
+				})
+			}
+		case cvmarkdown.Certifications:
+			for _, entry := range section.Entries {
+				cvData.Certifications = append(cvData.Certifications, Certification{
+					Name:         entry.Heading,
+					Issuer:       entry.Fields.Get("issuer"),
+					CredentialID: entry.Fields.Get("credential_id"),
+					Issued:       entry.Fields.Get("issued"),
+					Expires:      entry.Fields.Get("expires"),
+					URL:          entry.Fields.Get("url"),
+				})
+			}
+		case cvmarkdown.Interests:
+			cvData.Interests = texts(taggedItems(section.Items))
+		}
+	}
+	return cvData, nil
+}
+
+// markdownFields builds fields from alternating keys and values.
+func markdownFields(pairs ...string) cvmarkdown.Fields {
+	var fields cvmarkdown.Fields
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fields = append(fields, cvmarkdown.Field{Key: pairs[i], Value: pairs[i+1]})
+	}
+	return fields
+}
+
+func markdownItems(items []TaggedText) []cvmarkdown.Item {
+	result := make([]cvmarkdown.Item, len(items))
+	for i, item := range items {
+		result[i] = cvmarkdown.Item{Text: item.Text, Tags: item.Tags}
+	}
+	return result
+}
+
+func taggedItems(items []cvmarkdown.Item) []TaggedText {
+	result := make([]TaggedText, len(items))
+	for i, item := range items {
+		result[i] = TaggedText{Text: item.Text, Tags: normalizeTags(item.Tags)}
+	}
+	return result
+}
+
+func yearMonthText(ym *YearMonth) string {
+	if ym == nil {
+		return ""
+	}
+	text, _ := ym.MarshalText()
+	return string(text)
+}
+
+func markdownYearMonth(fields cvmarkdown.Fields, key string) (*YearMonth, error) {
+	f, ok := fields.Lookup(key)
+	if !ok || f.Value == "" {
+		return nil, nil
+	}
+	var ym YearMonth
+	if err := ym.UnmarshalText([]byte(f.Value)); err != nil {
+		return nil, &cvmarkdown.Error{Line: f.Line, Message: key + ": " + err.Error()}
+	}
+	return &ym, nil
+}
+
+// parseMarkdownCV parses a Markdown CV, returning a *cvmarkdown.Error for
+// problems with the document.
+func parseMarkdownCV(data []byte) (CVData, error) {
+	doc, err := cvmarkdown.Parse(data)
+	if err != nil {
+		return CVData{}, err
+	}
+	return fromMarkdown(doc)
+}
+
+func exportMarkdown(c *gin.Context) {
+	var cvData CVData
+	if !bindCV(c, &cvData) {
+		return
+	}
+
+	saveDownload(c, markdownDownload, toMarkdown(cvData).Bytes())
+}
+
+func importMarkdown(c *gin.Context) {
+	body, err := readImportBody(c)
+	if err != nil {
+		log.Printf("Error reading import body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cvData, err := parseMarkdownCV(body)
+	if err != nil {
+		var mdErr *cvmarkdown.Error
+		if !errors.As(err, &mdErr) {
+			mdErr = &cvmarkdown.Error{Message: err.Error()}
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Invalid Markdown CV",
+			"problems": []fieldError{{Line: mdErr.Line, Message: mdErr.Message}},
+		})
+		return
+	}
+
+	normalizeCVData(&cvData)
+	if problems := validateCVData(cvData); len(problems) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Invalid CV data",
+			"problems": problems,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cv": cvData,
+	})
+}
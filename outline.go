@@ -0,0 +1,95 @@
+package main
+
+import "strings"
+
+// cvOutline is a presentation-neutral view of a CV as headed sections of
+// simple blocks. Export formats that cannot use the HTML templates, such as
+// plain text, render from it so they all share one section order and one
+// set of headings.
+type cvOutline struct {
+	Name     string
+	Contact  []string
+	Sections []outlineSection
+}
+
+type outlineSection struct {
+	Title  string
+	Blocks []outlineBlock
+}
+
+// outlineBlock is rendered as an optional heading, then its lines as
+// paragraphs, then its bullets as a list.
+type outlineBlock struct {
+	Heading string
+	Lines   []string
+	Bullets []string
+}
+
+// buildOutline applies the render options and lays the CV out with the
+// standard section headings ATS parsers look for. Empty sections are left
+// out.
+func buildOutline(cvData CVData, opts renderOptions) cvOutline {
+	cvData = prepareCVData(cvData, opts)
+	outline := cvOutline{
+		Name:    cvData.Name,
+		Contact: compactStrings([]string{cvData.Address, cvData.Phone1, cvData.Phone2, cvData.Email}),
+	}
+	add := func(title string, blocks ...outlineBlock) {
+		if len(blocks) > 0 {
+			outline.Sections = append(outline.Sections, outlineSection{Title: title, Blocks: blocks})
+		}
+	}
+
+	summary := compactStrings(strings.Split(cvData.Statement, "\n"))
+	if tenure := experienceSummary(cvData.Experience, opts); tenure != "" {
+		summary = append([]string{tenure}, summary...)
+	}
+	if len(summary) > 0 {
+		add("Summary", outlineBlock{Lines: summary})
+	}
+
+	if len(cvData.Skills) > 0 {
+		add("Skills", outlineBlock{Bullets: texts(cvData.Skills)})
+	}
+
+	var experience []outlineBlock
+	for _, exp := range cvData.Experience {
+		experience = append(experience, outlineBlock{
+			Heading: exp.Title,
+			Lines:   compactStrings([]string{joinNonEmpty(" | ", exp.Company, exp.DateRange(opts.DateFormat))}),
+			Bullets: texts(exp.Duties),
+		})
+	}
+	add("Work Experience", experience...)
+
+	var education []outlineBlock
+	for _, edu := range cvData.Education {
+		lines := compactStrings([]string{joinNonEmpty(" | ", edu.Institution, edu.Period(opts.DateFormat))})
+		if edu.Grade != "" {
+			lines = append(lines, "Grade: "+edu.Grade)
+		}
+		education = append(education, outlineBlock{
+			Heading: joinNonEmpty(" in ", edu.Qualification, edu.Field),
+			Lines:   append(lines, compactStrings(strings.Split(edu.Notes, "\n"))...),
+		})
+	}
+	add("Education", education...)
+
+	var certifications []outlineBlock
+	for _, cert := range cvData.Certifications {
+		heading := cert.Name
+		if opts.flagExpired(cert) {
+			heading += " (expired)"
+		}
+		certifications = append(certifications, outlineBlock{
+			Heading: heading,
+			Lines:   compactStrings([]string{cert.Details(opts.DateFormat), cert.URL}),
+		})
+	}
+	add("Certifications", certifications...)
+
+	if len(cvData.Interests) > 0 {
+		add("Interests", outlineBlock{Bullets: cvData.Interests})
+	}
+	return outline
+}
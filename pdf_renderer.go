@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// pdfRenderer converts a complete HTML document into a PDF. Implementations
+// return the PDF as a stream so callers can forward it without buffering.
+type pdfRenderer interface {
+	Name() string
+	Render(ctx context.Context, html io.Reader) (io.ReadCloser, error)
+}
+
+// renderer is the PDF backend configured at startup.
+var renderer pdfRenderer
+
+// renderTimeout bounds a single PDF render, configured with RENDER_TIMEOUT.
+var renderTimeout = getEnvDuration("RENDER_TIMEOUT", 60*time.Second)
+
+// newPDFRenderer builds the renderer selected by PDF_RENDERER.
+func newPDFRenderer() (pdfRenderer, error) {
+	switch name := getEnv("PDF_RENDERER", "gotenberg"); name {
+	case "gotenberg":
+		return newGotenbergRenderer(getEnv("GOTENBERG_URL", "http://gotenberg:3000")), nil
+	case "chrome":
+		return newChromeRenderer(getEnv("CHROME_PATH", ""), getEnv("CHROME_NO_SANDBOX", "false") == "true")
+	case "basic":
+		return basicRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown PDF renderer %q", name)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// failureLimiter counts failed attempts per key, such as a password form and
+// the client's address. Once a key reaches the limit it is refused until the
+// window that began with its first failure has passed.
+type failureLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu       sync.Mutex
+	failures map[string]*failureWindow
+}
+
+type failureWindow struct {
+	count int
+	start time.Time
+}
+
+// maxTrackedKeys caps how many keys are kept. When it is reached, expired
+// windows are swept out and, if none have expired, the oldest is evicted, so
+// a burst of one-off failures cannot grow the map without bound.
+const maxTrackedKeys = 1024
+
+func newFailureLimiter(limit int, window time.Duration) *failureLimiter {
+	return &failureLimiter{limit: limit, window: window, failures: make(map[string]*failureWindow)}
+}
+
+// retryAfter reports how long key must wait before trying again, or zero if
+// it has attempts left.
+func (l *failureLimiter) retryAfter(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w, ok := l.failures[key]
+	if !ok || w.count < l.limit {
+		return 0
+	}
+	if wait := w.start.Add(l.window).Sub(now); wait > 0 {
+		return wait
+	}
+	delete(l.failures, key)
+	return 0
+}
+
+// fail records a failed attempt for key.
+func (l *failureLimiter) fail(key string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w, ok := l.failures[key]
+	if !ok && len(l.failures) >= maxTrackedKeys {
+		l.evict(now)
+	}
+	if !ok || !now.Before(w.start.Add(l.window)) {
+		w = &failureWindow{start: now}
+		l.failures[key] = w
+	}
+	w.count++
+}
+
+// evict removes expired windows, or the oldest one if none have expired.
+func (l *failureLimiter) evict(now time.Time) {
+	var oldest string
+	for k, w := range l.failures {
+		if !now.Before(w.start.Add(l.window)) {
+			delete(l.failures, k)
+		} else if oldest == "" || w.start.Before(l.failures[oldest].start) {
+			oldest = k
+		}
+	}
+	if len(l.failures) >= maxTrackedKeys {
+		delete(l.failures, oldest)
+	}
+}
+
+// reset forgets the failures of key after a successful attempt.
+func (l *failureLimiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, key)
+}
+
+// setRetryAfter tells a refused client how many seconds to wait.
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFailureLimiter(t *testing.T) {
+	l := newFailureLimiter(3, time.Minute)
+	start := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		if wait := l.retryAfter("a", start); wait != 0 {
+			t.Fatalf("attempt %d refused with %v to wait", i+1, wait)
+		}
+		l.fail("a", start.Add(time.Duration(i)*time.Second))
+	}
+	if wait := l.retryAfter("a", start.Add(10*time.Second)); wait != 50*time.Second {
+		t.Errorf("after the limit, wait = %v, want 50s", wait)
+	}
+	if wait := l.retryAfter("b", start); wait != 0 {
+		t.Errorf("other key refused with %v to wait", wait)
+	}
+	if wait := l.retryAfter("a", start.Add(time.Minute)); wait != 0 {
+		t.Errorf("after the window, wait = %v, want 0", wait)
+	}
+}
+
+func TestFailureLimiterReset(t *testing.T) {
+	l := newFailureLimiter(2, time.Minute)
+	now := time.Now()
+	l.fail("a", now)
+	l.reset("a")
+	l.fail("a", now)
+	if wait := l.retryAfter("a", now); wait != 0 {
+		t.Errorf("failures before a reset still counted: wait = %v", wait)
+	}
+}
+
+func TestFailureLimiterSweepsExpired(t *testing.T) {
+	l := newFailureLimiter(1, time.Minute)
+	start := time.Now()
+	for i := 0; i < maxTrackedKeys; i++ {
+		l.fail(string(rune('a'+i%26))+string(rune(i)), start)
+	}
+	l.fail("late", start.Add(2*time.Minute))
+	if len(l.failures) != 1 {
+		t.Errorf("tracked %d keys after sweep, want 1", len(l.failures))
+	}
+}
+
+func TestFailureLimiterEvictsOldest(t *testing.T) {
+	l := newFailureLimiter(1, time.Hour)
+	start := time.Now()
+	for i := 0; i < maxTrackedKeys+10; i++ {
+		l.fail(strconv.Itoa(i), start.Add(time.Duration(i)*time.Millisecond))
+	}
+	if len(l.failures) != maxTrackedKeys {
+		t.Errorf("tracked %d keys, want at most %d", len(l.failures), maxTrackedKeys)
+	}
+	if _, ok := l.failures["0"]; ok {
+		t.Error("oldest key kept after the cap was reached")
+	}
+	if wait := l.retryAfter(strconv.Itoa(maxTrackedKeys+9), start); wait == 0 {
+		t.Error("newest key not tracked")
+	}
+}
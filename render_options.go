@@ -0,0 +1,101 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dateFormats maps the date_format query values onto time layouts.
+var dateFormats = map[string]string{
+	"short":   "Jan 2006",
+	"long":    "January 2006",
+	"numeric": "01/2006",
+	"iso":     "2006-01",
+}
+
+const (
+	expiredShow = "show"
+	expiredFlag = "flag"
+	expiredHide = "hide"
+)
+
+// renderOptions controls presentation choices made at render time, as opposed
+// to the content itself which always comes from CVData.
+type renderOptions struct {
+	// Template is the ID of the layout in cvTemplates.
+	Template string
+	// ExpiredCertifications is one of expiredShow, expiredFlag or expiredHide.
+	ExpiredCertifications string
+	// DateFormat is the time layout used for experience date ranges.
+	DateFormat          string
+	SortExperience      bool
+	ShowExperienceYears bool
+	// Tags selects which tagged skills, roles and duties are rendered.
+	Tags tagFilter
+	Now  time.Time
+}
+
+func defaultRenderOptions() renderOptions {
+	return renderOptions{
+		Template:              defaultTemplate,
+		ExpiredCertifications: expiredFlag,
+		DateFormat:            dateFormats["short"],
+		Now:                   time.Now(),
+	}
+}
+
+// parseRenderOptions reads render options from the query string, falling
+// back to the defaults for missing or unrecognised values.
+func parseRenderOptions(c *gin.Context) renderOptions {
+	opts := defaultRenderOptions()
+	if template := c.Query("template"); template != "" {
+		opts.Template = template
+	}
+	switch expired := c.Query("expired"); expired {
+	case expiredShow, expiredFlag, expiredHide:
+		opts.ExpiredCertifications = expired
+	}
+	if layout, ok := dateFormats[c.Query("date_format")]; ok {
+		opts.DateFormat = layout
+	}
+	opts.SortExperience = c.Query("sort") == "date"
+	opts.ShowExperienceYears = c.Query("experience_years") == "true"
+	opts.Tags = tagFilter{
+		Include: parseTagList(c.Query("include_tags")),
+		Exclude: parseTagList(c.Query("exclude_tags")),
+	}
+	return opts
+}
+
+// prepareCVData applies the content-affecting render options, returning the
+// document the template should actually render.
+func prepareCVData(cvData CVData, opts renderOptions) CVData {
+	if opts.Tags.active() {
+		cvData = opts.Tags.apply(cvData)
+	}
+	experience := make([]Experience, len(cvData.Experience))
+	for i, exp := range cvData.Experience {
+		exp.resolvePeriod()
+		experience[i] = exp
+	}
+	if opts.SortExperience {
+		sortExperience(experience, opts.Now)
+	}
+	cvData.Experience = experience
+
+	if opts.ExpiredCertifications == expiredHide {
+		certifications := make([]Certification, 0, len(cvData.Certifications))
+		for _, cert := range cvData.Certifications {
+			if !cert.Expired(opts.Now) {
+				certifications = append(certifications, cert)
+			}
+		}
+		cvData.Certifications = certifications
+	}
+	return cvData
+}
+
+func (o renderOptions) flagExpired(cert Certification) bool {
+	return o.ExpiredCertifications == expiredFlag && cert.Expired(o.Now)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func listRevisions(c *gin.Context) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return
+	}
+	revisions, err := store.ListRevisions(c.Request.Context(), doc.ID)
+	if err != nil {
+		respondStoreError(c, err, "Error listing revisions", "Failed to list revisions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+func getRevision(c *gin.Context) {
+	revision, ok := loadRevision(c, c.Param("rev"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, revision)
+}
+
+// restoreRevision saves an older revision's content as a new revision, so
+// restoring never discards history.
+func restoreRevision(c *gin.Context) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return
+	}
+	revision, ok := loadRevision(c, c.Param("rev"))
+	if !ok {
+		return
+	}
+
+	doc.Data = *revision.Data
+	doc.UpdatedAt = time.Now().UTC()
+	if err := store.Update(c.Request.Context(), &doc, requestAuthor(c)); err != nil {
+		respondStoreError(c, err, "Error restoring revision", "Failed to restore revision")
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+// diffRevisions compares revision "from" with revision "to", which defaults
+// to the current one.
+func diffRevisions(c *gin.Context) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return
+	}
+	from, ok := loadRevision(c, c.Query("from"))
+	if !ok {
+		return
+	}
+	to := cvRevision{Number: doc.Revision, Data: &doc.Data}
+	if c.Query("to") != "" {
+		if to, ok = loadRevision(c, c.Query("to")); !ok {
+			return
+		}
+	}
+
+	diff := diffCVData(*from.Data, *to.Data)
+	diff.From, diff.To = from.Number, to.Number
+	c.JSON(http.StatusOK, diff)
+}
+
+func loadRevision(c *gin.Context, rev string) (cvRevision, bool) {
+	number, err := strconv.Atoi(rev)
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision number"})
+		return cvRevision{}, false
+	}
+	revision, err := store.GetRevision(c.Request.Context(), c.Param("id"), number)
+	if errors.Is(err, errNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return cvRevision{}, false
+	}
+	if err != nil {
+		respondStoreError(c, err, "Error loading revision", "Failed to load revision")
+		return cvRevision{}, false
+	}
+	return revision, true
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Kinds of share event.
+const (
+	shareView     = "view"
+	shareDownload = "download"
+)
+
+// Agent classes. Only the class is stored, never the user agent itself.
+const (
+	agentBot     = "bot"
+	agentMobile  = "mobile"
+	agentTablet  = "tablet"
+	agentDesktop = "desktop"
+	agentOther   = "other"
+)
+
+// shareEvent is one view or PDF download of a share link. Visits are kept
+// coarse on purpose: no IP address, full user agent or referrer path.
+type shareEvent struct {
+	ShareID string
+	Kind    string
+	Agent   string
+	// Referrer is the host of the page that linked to the share, if any.
+	Referrer  string
+	CreatedAt time.Time
+}
+
+// shareStats summarises the events of one link. Bots such as link preview
+// fetchers are counted only in Agents, so the other figures reflect people.
+type shareStats struct {
+	ShareID          string          `json:"share_id"`
+	DoNotTrack       bool            `json:"do_not_track"`
+	Views            int             `json:"views"`
+	Downloads        int             `json:"downloads"`
+	FirstViewedAt    *time.Time      `json:"first_viewed_at"`
+	LastViewedAt     *time.Time      `json:"last_viewed_at"`
+	LastDownloadedAt *time.Time      `json:"last_downloaded_at"`
+	Agents           map[string]int  `json:"agents"`
+	Referrers        []referrerCount `json:"referrers"`
+}
+
+type referrerCount struct {
+	Host  string `json:"host"`
+	Views int    `json:"views"`
+}
+
+func getShareAnalytics(c *gin.Context) {
+	share, err := store.GetShare(c.Request.Context(), c.Param("id"), c.Param("share"))
+	if err != nil {
+		respondShareError(c, err, "Error loading share", "Failed to load share link")
+		return
+	}
+	stats, err := store.ShareStats(c.Request.Context(), share.ID)
+	if err != nil {
+		log.Printf("Error loading share analytics: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load analytics"})
+		return
+	}
+	stats.ShareID = share.ID
+	stats.DoNotTrack = share.DoNotTrack
+	c.JSON(http.StatusOK, stats)
+}
+
+// recordShareEvent notes a visit unless the link has tracking turned off or
+// the visitor's browser asks not to be tracked. Failures are only logged, so
+// they never get in the visitor's way.
+func recordShareEvent(c *gin.Context, share cvShare, kind string) {
+	if share.DoNotTrack || c.GetHeader("DNT") == "1" || c.GetHeader("Sec-GPC") == "1" {
+		return
+	}
+	event := shareEvent{
+		ShareID:   share.ID,
+		Kind:      kind,
+		Agent:     classifyUserAgent(c.Request.UserAgent()),
+		Referrer:  referrerHost(c.Request.Referer(), c.Request.Host),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := store.RecordShareEvent(c.Request.Context(), event); err != nil {
+		log.Printf("Error recording share event: %v", err)
+	}
+}
+
+var botMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "preview", "facebookexternalhit", "embedly",
+	"curl", "wget", "python-", "go-http-client", "java/", "headless",
+}
+
+// classifyUserAgent sorts a User-Agent header into one of the agent classes.
+func classifyUserAgent(ua string) string {
+	ua = strings.ToLower(ua)
+	switch {
+	case ua == "":
+		return agentOther
+	case containsAny(ua, botMarkers):
+		return agentBot
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet") ||
+		(strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		return agentTablet
+	case strings.Contains(ua, "mobi") || strings.Contains(ua, "iphone") || strings.Contains(ua, "android"):
+		return agentMobile
+	case strings.Contains(ua, "mozilla/"):
+		return agentDesktop
+	default:
+		return agentOther
+	}
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// referrerHost reduces a Referer header to its host, dropping "www." and
+// ignoring links from this server, such as the password page.
+func referrerHost(referer, ownHost string) string {
+	u, err := url.Parse(referer)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	if strings.EqualFold(u.Host, ownHost) {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if own, _, err := net.SplitHostPort(ownHost); err == nil && host == strings.ToLower(own) {
+		return ""
+	}
+	return strings.TrimPrefix(host, "www.")
+}
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// cvShare is a read-only public link to a saved CV. The page always renders
+// the CV's current content, so later edits reach everyone holding the link
+// until it expires or is revoked.
+type cvShare struct {
+	ID           string     `json:"id"`
+	CVID         string     `json:"cv_id"`
+	Token        string     `json:"token"`
+	Template     string     `json:"template"`
+	PasswordHash string     `json:"-"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	// DoNotTrack stops visits to the link being recorded.
+	DoNotTrack bool `json:"do_not_track"`
+}
+
+const (
+	shareActive  = "active"
+	shareExpired = "expired"
+	shareRevoked = "revoked"
+)
+
+// shareCookie holds proof that the visitor entered a share's password. It is
+// scoped to the share's path, so one name serves every link.
+const shareCookie = "cv_share"
+
+func (s cvShare) status(now time.Time) string {
+	switch {
+	case s.RevokedAt != nil:
+		return shareRevoked
+	case s.ExpiresAt != nil && !now.Before(*s.ExpiresAt):
+		return shareExpired
+	default:
+		return shareActive
+	}
+}
+
+// path is the public page for the share.
+func (s cvShare) path() string {
+	return "/p/" + s.Token
+}
+
+func (s cvShare) renderOptions() renderOptions {
+	opts := defaultRenderOptions()
+	opts.Template = s.Template
+	return opts
+}
+
+// MarshalJSON adds the public path, the current status and whether a
+// password is set; the password hash itself is never sent.
+func (s cvShare) MarshalJSON() ([]byte, error) {
+	type plain cvShare
+	return json.Marshal(struct {
+		plain
+		URL               string `json:"url"`
+		Status            string `json:"status"`
+		PasswordProtected bool   `json:"password_protected"`
+	}{plain(s), s.path(), s.status(time.Now()), s.PasswordHash != ""})
+}
+
+func listShares(c *gin.Context) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return
+	}
+	shares, err := store.ListShares(c.Request.Context(), doc.ID)
+	if err != nil {
+		log.Printf("Error listing shares: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list share links"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+// createShare publishes a saved CV. The body is optional; it may choose the
+// template, set an expiry time and a password, and turn off view tracking.
+func createShare(c *gin.Context) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Template   string     `json:"template"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+		Password   string     `json:"password"`
+		DoNotTrack bool       `json:"do_not_track"`
+	}
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		log.Printf("Error binding JSON: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	now := time.Now().UTC()
+	if body.Template == "" {
+		body.Template = defaultTemplate
+	}
+	var problems []fieldError
+	if _, ok := lookupTemplate(body.Template); !ok {
+		problems = append(problems, fieldError{Field: "template", Message: "is not a known template"})
+	}
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(now) {
+		problems = append(problems, fieldError{Field: "expires_at", Message: "must be in the future"})
+	}
+	// bcrypt ignores anything past 72 bytes, so longer passwords would be
+	// weaker than they look.
+	if len(body.Password) > 72 {
+		problems = append(problems, fieldError{Field: "password", Message: "must be at most 72 bytes"})
+	}
+	if len(problems) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Invalid share link",
+			"problems": problems,
+		})
+		return
+	}
+
+	share := cvShare{
+		ID:         uuid.New().String(),
+		CVID:       doc.ID,
+		Template:   body.Template,
+		CreatedAt:  now,
+		DoNotTrack: body.DoNotTrack,
+	}
+	if body.ExpiresAt != nil {
+		expiresAt := body.ExpiresAt.UTC()
+		share.ExpiresAt = &expiresAt
+	}
+	var err error
+	if share.Token, err = newShareToken(); err != nil {
+		log.Printf("Error generating share token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+	if body.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
+		if err != nil {
+			log.Printf("Error hashing share password: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+			return
+		}
+		share.PasswordHash = string(hash)
+	}
+
+	if err := store.CreateShare(c.Request.Context(), &share); err != nil {
+		log.Printf("Error saving share: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/cvs/%s/shares/%s", doc.ID, share.ID))
+	c.JSON(http.StatusCreated, share)
+}
+
+// updateShare changes the settings of an existing link. Only do_not_track
+// can be changed; turning it on also deletes the visits recorded so far.
+func updateShare(c *gin.Context) {
+	var body struct {
+		DoNotTrack *bool `json:"do_not_track"`
+	}
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		log.Printf("Error binding JSON: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if body.DoNotTrack != nil {
+		if err := store.SetShareDoNotTrack(ctx, c.Param("id"), c.Param("share"), *body.DoNotTrack); err != nil {
+			respondShareError(c, err, "Error updating share", "Failed to update share link")
+			return
+		}
+	}
+	share, err := store.GetShare(ctx, c.Param("id"), c.Param("share"))
+	if err != nil {
+		respondShareError(c, err, "Error loading share", "Failed to load share link")
+		return
+	}
+	c.JSON(http.StatusOK, share)
+}
+
+// revokeShare stops a link from working. The record is kept so the owner
+// can still see what was shared.
+func revokeShare(c *gin.Context) {
+	err := store.RevokeShare(c.Request.Context(), c.Param("id"), c.Param("share"), time.Now().UTC())
+	if err != nil {
+		respondShareError(c, err, "Error revoking share", "Failed to revoke share link")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func respondShareError(c *gin.Context, err error, logPrefix, message string) {
+	if errors.Is(err, errNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return
+	}
+	respondStoreError(c, err, logPrefix, message)
+}
+
+// newShareToken returns an unguessable URL-safe token.
+func newShareToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sharedPageHeaders keeps public CV pages out of search engines and caches,
+// and stops the token leaking to sites the CV links to.
+func sharedPageHeaders(c *gin.Context) {
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "no-store")
+	c.Next()
+}
+
+// viewShare serves the public page for a share link, asking for the password
+// first if the link has one.
+func viewShare(c *gin.Context) {
+	share, doc, ok := loadPublicShare(c)
+	if !ok {
+		return
+	}
+	if !shareUnlocked(c, share) {
+		renderSharedPage(c, http.StatusUnauthorized, sharePasswordPage(share.path(), false))
+		return
+	}
+
+	page, err := renderCVHTML(c.Request.Context(), doc.Data, share.renderOptions())
+	if err == nil {
+		var data []byte
+		if data, err = sharePageHTML(c.Request.Context(), page.Bytes(), share.path()+"/pdf"); err == nil {
+			recordShareEvent(c, share, shareView)
+			c.Data(http.StatusOK, "text/html; charset=utf-8", data)
+			return
+		}
+	}
+	log.Printf("Error rendering shared CV: %v", err)
+	renderSharedPage(c, http.StatusInternalServerError,
+		shareMessagePage("Something went wrong", "This CV could not be displayed. Please try again later."))
+}
+
+// sharePasswordAttempts limits wrong passwords per share and client address,
+// so short share passwords cannot be guessed by trying them all.
+var sharePasswordAttempts = newFailureLimiter(10, 15*time.Minute)
+
+// unlockShare checks a password submitted from the password page and, if it
+// is right, sets the cookie that lets the visitor see the CV.
+func unlockShare(c *gin.Context) {
+	share, _, ok := loadPublicShare(c)
+	if !ok {
+		return
+	}
+	if share.PasswordHash != "" {
+		key := share.ID + " " + c.ClientIP()
+		if wait := sharePasswordAttempts.retryAfter(key, time.Now()); wait > 0 {
+			setRetryAfter(c, wait)
+			renderSharedPage(c, http.StatusTooManyRequests, shareMessagePage("Too many attempts",
+				fmt.Sprintf("Too many incorrect passwords were entered. Try again in %d minutes.", int(math.Ceil(wait.Minutes())))))
+			return
+		}
+		err := bcrypt.CompareHashAndPassword([]byte(share.PasswordHash), []byte(c.PostForm("password")))
+		if err != nil {
+			sharePasswordAttempts.fail(key, time.Now())
+			renderSharedPage(c, http.StatusUnauthorized, sharePasswordPage(share.path(), true))
+			return
+		}
+		sharePasswordAttempts.reset(key)
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(shareCookie, shareCookieValue(share), 0, share.path(), "", isHTTPS(c), true)
+	}
+	c.Redirect(http.StatusSeeOther, share.path())
+}
+
+// downloadSharePDF renders the shared CV to PDF for the page's download
+// button.
+func downloadSharePDF(c *gin.Context) {
+	share, doc, ok := loadPublicShare(c)
+	if !ok {
+		return
+	}
+	if !shareUnlocked(c, share) {
+		c.Redirect(http.StatusSeeOther, share.path())
+		return
+	}
+
+	page, err := renderCVHTML(c.Request.Context(), doc.Data, share.renderOptions())
+	if err != nil {
+		log.Printf("Error rendering shared CV: %v", err)
+		renderSharedPage(c, http.StatusInternalServerError,
+			shareMessagePage("Something went wrong", "The PDF could not be generated. Please try again later."))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), renderTimeout)
+	defer cancel()
+
+	pdf, err := renderer.Render(ctx, page)
+	if err != nil {
+		log.Printf("Error rendering PDF with %s: %v", renderer.Name(), err)
+		renderSharedPage(c, http.StatusInternalServerError,
+			shareMessagePage("Something went wrong", "The PDF could not be generated. Please try again later."))
+		return
+	}
+	defer pdf.Close()
+	recordShareEvent(c, share, shareDownload)
+	streamPDF(c, pdf)
+}
+
+// loadPublicShare looks up the share named in the URL together with its CV,
+// answering with an explanatory page if the link cannot be used.
+func loadPublicShare(c *gin.Context) (cvShare, cvDocument, bool) {
+	ctx := c.Request.Context()
+	share, err := store.GetShareByToken(ctx, c.Param("token"))
+	if err == nil {
+		switch share.status(time.Now()) {
+		case shareRevoked:
+			renderSharedPage(c, http.StatusGone,
+				shareMessagePage("Link no longer available", "The owner of this CV has stopped sharing it."))
+			return cvShare{}, cvDocument{}, false
+		case shareExpired:
+			renderSharedPage(c, http.StatusGone,
+				shareMessagePage("Link expired", "This link has expired. Ask the owner of the CV for a new one."))
+			return cvShare{}, cvDocument{}, false
+		}
+	}
+
+	var doc cvDocument
+	if err == nil {
+		doc, err = store.Get(ctx, share.CVID)
+	}
+	if errors.Is(err, errNotFound) {
+		renderSharedPage(c, http.StatusNotFound,
+			shareMessagePage("Link not found", "This link does not exist. Check that it was copied in full."))
+		return cvShare{}, cvDocument{}, false
+	}
+	if err != nil {
+		log.Printf("Error loading share: %v", err)
+		renderSharedPage(c, http.StatusInternalServerError,
+			shareMessagePage("Something went wrong", "This CV could not be loaded. Please try again later."))
+		return cvShare{}, cvDocument{}, false
+	}
+	return share, doc, true
+}
+
+// shareUnlocked reports whether the visitor may see the share, either
+// because it has no password or because they have entered it.
+func shareUnlocked(c *gin.Context, share cvShare) bool {
+	if share.PasswordHash == "" {
+		return true
+	}
+	cookie, err := c.Cookie(shareCookie)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(shareCookieValue(share))) == 1
+}
+
+// shareCookieValue is derived from the token and the stored password hash,
+// so it cannot be produced without access to the database and stops working
+// if the password changes.
+func shareCookieValue(share cvShare) string {
+	sum := sha256.Sum256([]byte(share.Token + "\x00" + share.PasswordHash))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// trustedProxies lists the addresses and CIDR ranges, from TRUSTED_PROXIES,
+// whose forwarded client address and protocol headers are believed.
+var trustedProxies = compactStrings(strings.Split(getEnv("TRUSTED_PROXIES", ""), ","))
+
+// isHTTPS reports whether the client connected over HTTPS, directly or
+// through a trusted proxy that sets X-Forwarded-Proto.
+func isHTTPS(c *gin.Context) bool {
+	if c.Request.TLS != nil {
+		return true
+	}
+	return c.GetHeader("X-Forwarded-Proto") == "https" && fromTrustedProxy(c)
+}
+
+// fromTrustedProxy reports whether the request came directly from one of
+// trustedProxies.
+func fromTrustedProxy(c *gin.Context) bool {
+	remote, err := netip.ParseAddr(c.RemoteIP())
+	if err != nil {
+		return false
+	}
+	remote = remote.Unmap()
+	for _, proxy := range trustedProxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			if prefix.Contains(remote) {
+				return true
+			}
+		} else if addr, err := netip.ParseAddr(proxy); err == nil && addr.Unmap() == remote {
+			return true
+		}
+	}
+	return false
+}
+
+func renderSharedPage(c *gin.Context, status int, page templ.Component) {
+	var buf bytes.Buffer
+	if err := page.Render(c.Request.Context(), &buf); err != nil {
+		log.Printf("Error rendering page: %v", err)
+		c.String(http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// sharePageHTML lays a rendered CV out for reading on screen and adds the
+// toolbar with the PDF download button.
+func sharePageHTML(ctx context.Context, page []byte, pdfURL string) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+	if err := addScreenStylesheet(doc); err != nil {
+		return nil, err
+	}
+	body := findElement(doc, atom.Body)
+	if body == nil {
+		return nil, errors.New("page has no body")
+	}
+
+	var toolbar bytes.Buffer
+	if err := shareToolbar(pdfURL).Render(ctx, &toolbar); err != nil {
+		return nil, err
+	}
+	nodes, err := html.ParseFragment(&toolbar, body)
+	if err != nil {
+		return nil, err
+	}
+	first := body.FirstChild
+	for _, node := range nodes {
+		body.InsertBefore(node, first)
+	}
+	return renderHTML(doc)
+}
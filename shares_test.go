@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// useTestStore points the global store at a fresh SQLite database for the
+// duration of the test.
+func useTestStore(t *testing.T) {
+	t.Helper()
+	s, err := openSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	previous := store
+	store = s
+	t.Cleanup(func() {
+		store = previous
+		s.Close()
+	})
+}
+
+func sharedRouter() *gin.Engine {
+	r := gin.New()
+	shared := r.Group("/p", sharedPageHeaders)
+	shared.GET("/:token", viewShare)
+	shared.POST("/:token", unlockShare)
+	return r
+}
+
+// newTestUser saves an account with the given email and password.
+func newTestUser(t *testing.T, email, password string) userAccount {
+	t.Helper()
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	user := userAccount{ID: uuid.New().String(), Email: email, PasswordHash: string(hash), CreatedAt: time.Now()}
+	if err := store.CreateUser(context.Background(), &user); err != nil {
+		t.Fatalf("saving user: %v", err)
+	}
+	return user
+}
+
+// newTestCV saves a CV owned by user.
+func newTestCV(t *testing.T, user userAccount, name string) cvDocument {
+	t.Helper()
+	now := time.Now()
+	doc := cvDocument{ID: uuid.New().String(), OwnerID: user.ID, Data: CVData{Name: name}, CreatedAt: now, UpdatedAt: now}
+	if err := store.Create(context.Background(), &doc, user.displayName()); err != nil {
+		t.Fatalf("saving CV: %v", err)
+	}
+	return doc
+}
+
+// newTestShare saves a CV and shares it with the given password.
+func newTestShare(t *testing.T, password string, configure func(*cvShare)) cvShare {
+	t.Helper()
+	ctx := context.Background()
+	doc := newTestCV(t, newTestUser(t, uuid.New().String()+"@example.com", "password"), "Jane Doe")
+	token, _ := newShareToken()
+	share := cvShare{ID: "share-" + token, CVID: doc.ID, Token: token, Template: defaultTemplate, CreatedAt: time.Now()}
+	if password != "" {
+		hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		share.PasswordHash = string(hash)
+	}
+	if configure != nil {
+		configure(&share)
+	}
+	if err := store.CreateShare(ctx, &share); err != nil {
+		t.Fatalf("saving share: %v", err)
+	}
+	return share
+}
+
+func serve(r http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func postPassword(path, password string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(url.Values{"password": {password}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestShareStatus(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	tests := []struct {
+		share cvShare
+		want  string
+	}{
+		{cvShare{}, shareActive},
+		{cvShare{ExpiresAt: &future}, shareActive},
+		{cvShare{ExpiresAt: &now}, shareExpired},
+		{cvShare{ExpiresAt: &past}, shareExpired},
+		{cvShare{RevokedAt: &past, ExpiresAt: &future}, shareRevoked},
+	}
+	for i, tt := range tests {
+		if got := tt.share.status(now); got != tt.want {
+			t.Errorf("case %d: status = %q, want %q", i, got, tt.want)
+		}
+	}
+}
+
+func TestViewShare(t *testing.T) {
+	useTestStore(t)
+	r := sharedRouter()
+	past := time.Now().Add(-time.Hour)
+
+	open := newTestShare(t, "", nil)
+	w := serve(r, httptest.NewRequest(http.MethodGet, open.path(), nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Jane Doe") {
+		t.Errorf("open share: %d", w.Code)
+	}
+	if w.Header().Get("X-Robots-Tag") == "" || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("open share served without privacy headers: %v", w.Header())
+	}
+
+	revoked := newTestShare(t, "", func(s *cvShare) { s.RevokedAt = &past })
+	if w := serve(r, httptest.NewRequest(http.MethodGet, revoked.path(), nil)); w.Code != http.StatusGone {
+		t.Errorf("revoked share: %d, want 410", w.Code)
+	}
+	expired := newTestShare(t, "", func(s *cvShare) { s.ExpiresAt = &past })
+	if w := serve(r, httptest.NewRequest(http.MethodGet, expired.path(), nil)); w.Code != http.StatusGone {
+		t.Errorf("expired share: %d, want 410", w.Code)
+	}
+	if w := serve(r, httptest.NewRequest(http.MethodGet, "/p/unknown", nil)); w.Code != http.StatusNotFound {
+		t.Errorf("unknown token: %d, want 404", w.Code)
+	}
+}
+
+func TestUnlockShare(t *testing.T) {
+	useTestStore(t)
+	r := sharedRouter()
+	share := newTestShare(t, "hunter22", nil)
+
+	w := serve(r, httptest.NewRequest(http.MethodGet, share.path(), nil))
+	if w.Code != http.StatusUnauthorized || strings.Contains(w.Body.String(), "Jane Doe") {
+		t.Fatalf("locked share: %d, CV shown: %v", w.Code, strings.Contains(w.Body.String(), "Jane Doe"))
+	}
+	if w := serve(r, postPassword(share.path(), "wrong")); w.Code != http.StatusUnauthorized || len(w.Result().Cookies()) != 0 {
+		t.Errorf("wrong password: %d with cookies %v", w.Code, w.Result().Cookies())
+	}
+
+	w = serve(r, postPassword(share.path(), "hunter22"))
+	cookies := w.Result().Cookies()
+	if w.Code != http.StatusSeeOther || len(cookies) != 1 {
+		t.Fatalf("right password: %d with cookies %v", w.Code, cookies)
+	}
+	if cookies[0].Path != share.path() || !cookies[0].HttpOnly {
+		t.Errorf("cookie not scoped to the share: %+v", cookies[0])
+	}
+
+	req := httptest.NewRequest(http.MethodGet, share.path(), nil)
+	req.AddCookie(cookies[0])
+	if w := serve(r, req); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Jane Doe") {
+		t.Errorf("unlocked share: %d", w.Code)
+	}
+
+	// The cookie of one share does not open another.
+	other := newTestShare(t, "hunter22", nil)
+	req = httptest.NewRequest(http.MethodGet, other.path(), nil)
+	req.AddCookie(&http.Cookie{Name: shareCookie, Value: cookies[0].Value})
+	if w := serve(r, req); w.Code != http.StatusUnauthorized {
+		t.Errorf("cookie reused on another share: %d, want 401", w.Code)
+	}
+}
+
+func TestUnlockShareLimitsAttempts(t *testing.T) {
+	useTestStore(t)
+	previous := sharePasswordAttempts
+	sharePasswordAttempts = newFailureLimiter(3, time.Minute)
+	t.Cleanup(func() { sharePasswordAttempts = previous })
+	r := sharedRouter()
+	share := newTestShare(t, "hunter22", nil)
+
+	for i := 0; i < 3; i++ {
+		if w := serve(r, postPassword(share.path(), "wrong")); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: %d, want 401", i+1, w.Code)
+		}
+	}
+	w := serve(r, postPassword(share.path(), "hunter22"))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("after the limit: %d with Retry-After %q, want 429", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Other visitors and other shares are not affected.
+	req := postPassword(share.path(), "hunter22")
+	req.RemoteAddr = "192.0.2.99:1234"
+	if w := serve(r, req); w.Code != http.StatusSeeOther {
+		t.Errorf("another address: %d, want 303", w.Code)
+	}
+	other := newTestShare(t, "hunter22", nil)
+	if w := serve(r, postPassword(other.path(), "hunter22")); w.Code != http.StatusSeeOther {
+		t.Errorf("another share: %d, want 303", w.Code)
+	}
+}
+
+func TestIsHTTPSTrustsOnlyConfiguredProxies(t *testing.T) {
+	previous := trustedProxies
+	trustedProxies = []string{"10.0.0.0/8", "192.0.2.1"}
+	t.Cleanup(func() { trustedProxies = previous })
+	tests := []struct {
+		remote string
+		want   bool
+	}{
+		{"10.1.2.3:4000", true},
+		{"192.0.2.1:4000", true},
+		{"[::ffff:192.0.2.1]:4000", true},
+		{"192.0.2.2:4000", false},
+		{"203.0.113.5:4000", false},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.RemoteAddr = tt.remote
+		c.Request.Header.Set("X-Forwarded-Proto", "https")
+		if got := isHTTPS(c); got != tt.want {
+			t.Errorf("isHTTPS from %s = %v, want %v", tt.remote, got, tt.want)
+		}
+	}
+}
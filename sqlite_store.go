@@ -0,0 +1,693 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteMigrations are applied in order, tracked with PRAGMA user_version.
+// Append new statements; never edit ones that have shipped.
+var sqliteMigrations = []string{
+	`CREATE TABLE cvs (
+		id         TEXT PRIMARY KEY,
+		data       TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	)`,
+	`ALTER TABLE cvs ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+	CREATE TABLE cv_revisions (
+		cv_id      TEXT NOT NULL REFERENCES cvs (id) ON DELETE CASCADE,
+		number     INTEGER NOT NULL,
+		data       TEXT NOT NULL,
+		author     TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (cv_id, number)
+	);
+	INSERT INTO cv_revisions (cv_id, number, data, author, created_at)
+		SELECT id, 1, data, '', updated_at FROM cvs`,
+	`CREATE TABLE cv_variants (
+		id         TEXT PRIMARY KEY,
+		cv_id      TEXT NOT NULL REFERENCES cvs (id) ON DELETE CASCADE,
+		name       TEXT NOT NULL,
+		overrides  TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX cv_variants_cv_id ON cv_variants (cv_id)`,
+	`CREATE TABLE cv_shares (
+		id            TEXT PRIMARY KEY,
+		cv_id         TEXT NOT NULL REFERENCES cvs (id) ON DELETE CASCADE,
+		token         TEXT NOT NULL UNIQUE,
+		template      TEXT NOT NULL,
+		password_hash TEXT NOT NULL DEFAULT '',
+		expires_at    TIMESTAMP,
+		revoked_at    TIMESTAMP,
+		created_at    TIMESTAMP NOT NULL
+	);
+	CREATE INDEX cv_shares_cv_id ON cv_shares (cv_id)`,
+	`ALTER TABLE cv_shares ADD COLUMN do_not_track INTEGER NOT NULL DEFAULT 0;
+	CREATE TABLE cv_share_events (
+		id         INTEGER PRIMARY KEY,
+		share_id   TEXT NOT NULL REFERENCES cv_shares (id) ON DELETE CASCADE,
+		kind       TEXT NOT NULL,
+		agent      TEXT NOT NULL,
+		referrer   TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX cv_share_events_share_id ON cv_share_events (share_id, kind)`,
+	`CREATE TABLE users (
+		id            TEXT PRIMARY KEY,
+		email         TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		name          TEXT NOT NULL,
+		password_hash TEXT NOT NULL,
+		created_at    TIMESTAMP NOT NULL
+	);
+	CREATE TABLE sessions (
+		token_hash TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX sessions_user_id ON sessions (user_id);
+	CREATE TABLE password_resets (
+		token_hash TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX password_resets_user_id ON password_resets (user_id);
+	ALTER TABLE cvs ADD COLUMN owner_id TEXT REFERENCES users (id) ON DELETE CASCADE;
+	CREATE INDEX cvs_owner_id ON cvs (owner_id)`,
+}
+
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating database directory: %w", err)
+	}
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	s := &sqliteStore{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *sqliteStore) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("starting migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("applying migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("recording migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("committing migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqliteStore) Create(ctx context.Context, doc *cvDocument, author string) error {
+	data, err := json.Marshal(doc.Data)
+	if err != nil {
+		return fmt.Errorf("encoding CV: %w", err)
+	}
+	doc.Revision = 1
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO cvs (id, owner_id, data, created_at, updated_at, revision) VALUES (?, ?, ?, ?, ?, ?)",
+			doc.ID, doc.OwnerID, string(data), doc.CreatedAt, doc.UpdatedAt, doc.Revision)
+		if err != nil {
+			return fmt.Errorf("inserting CV: %w", err)
+		}
+		return insertRevision(ctx, tx, doc, string(data), author)
+	})
+}
+
+func (s *sqliteStore) Get(ctx context.Context, id string) (cvDocument, error) {
+	doc := cvDocument{ID: id}
+	var data []byte
+	var owner sql.NullString
+	err := s.db.QueryRowContext(ctx,
+		"SELECT owner_id, data, created_at, updated_at, revision FROM cvs WHERE id = ?", id).
+		Scan(&owner, &data, &doc.CreatedAt, &doc.UpdatedAt, &doc.Revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cvDocument{}, errNotFound
+	}
+	if err != nil {
+		return cvDocument{}, fmt.Errorf("selecting CV: %w", err)
+	}
+	doc.OwnerID = owner.String
+	if err := json.Unmarshal(data, &doc.Data); err != nil {
+		return cvDocument{}, fmt.Errorf("decoding CV: %w", err)
+	}
+	return doc, nil
+}
+
+func (s *sqliteStore) List(ctx context.Context, ownerID string) ([]cvSummary, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, json_extract(data, '$.name'), updated_at FROM cvs WHERE owner_id = ? ORDER BY updated_at DESC",
+		ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("listing CVs: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []cvSummary{}
+	for rows.Next() {
+		var summary cvSummary
+		var name sql.NullString
+		if err := rows.Scan(&summary.ID, &name, &summary.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scanning CV: %w", err)
+		}
+		summary.Name = name.String
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
+
+func (s *sqliteStore) Update(ctx context.Context, doc *cvDocument, author string) error {
+	data, err := json.Marshal(doc.Data)
+	if err != nil {
+		return fmt.Errorf("encoding CV: %w", err)
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			"UPDATE cvs SET data = ?, updated_at = ?, revision = revision + 1 WHERE id = ? RETURNING revision",
+			string(data), doc.UpdatedAt, doc.ID).Scan(&doc.Revision)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("updating CV: %w", err)
+		}
+		return insertRevision(ctx, tx, doc, string(data), author)
+	})
+}
+
+func insertRevision(ctx context.Context, tx *sql.Tx, doc *cvDocument, data, author string) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO cv_revisions (cv_id, number, data, author, created_at) VALUES (?, ?, ?, ?, ?)",
+		doc.ID, doc.Revision, data, author, doc.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting revision: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) ListRevisions(ctx context.Context, id string) ([]cvRevision, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT number, author, created_at FROM cv_revisions WHERE cv_id = ? ORDER BY number DESC", id)
+	if err != nil {
+		return nil, fmt.Errorf("listing revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []cvRevision{}
+	for rows.Next() {
+		var revision cvRevision
+		if err := rows.Scan(&revision.Number, &revision.Author, &revision.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning revision: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+func (s *sqliteStore) GetRevision(ctx context.Context, id string, number int) (cvRevision, error) {
+	revision := cvRevision{Number: number}
+	var data []byte
+	err := s.db.QueryRowContext(ctx,
+		"SELECT data, author, created_at FROM cv_revisions WHERE cv_id = ? AND number = ?", id, number).
+		Scan(&data, &revision.Author, &revision.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cvRevision{}, errNotFound
+	}
+	if err != nil {
+		return cvRevision{}, fmt.Errorf("selecting revision: %w", err)
+	}
+	revision.Data = &CVData{}
+	if err := json.Unmarshal(data, revision.Data); err != nil {
+		return cvRevision{}, fmt.Errorf("decoding revision: %w", err)
+	}
+	return revision, nil
+}
+
+func (s *sqliteStore) Delete(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM cvs WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("deleting CV: %w", err)
+	}
+	return requireRow(result)
+}
+
+func (s *sqliteStore) CreateVariant(ctx context.Context, variant *cvVariant) error {
+	overrides, err := json.Marshal(variant.Overrides)
+	if err != nil {
+		return fmt.Errorf("encoding overrides: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		"INSERT INTO cv_variants (id, cv_id, name, overrides, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		variant.ID, variant.BaseID, variant.Name, string(overrides), variant.CreatedAt, variant.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting variant: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) GetVariant(ctx context.Context, cvID, id string) (cvVariant, error) {
+	variant := cvVariant{ID: id, BaseID: cvID}
+	var overrides []byte
+	err := s.db.QueryRowContext(ctx,
+		"SELECT name, overrides, created_at, updated_at FROM cv_variants WHERE id = ? AND cv_id = ?", id, cvID).
+		Scan(&variant.Name, &overrides, &variant.CreatedAt, &variant.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cvVariant{}, errNotFound
+	}
+	if err != nil {
+		return cvVariant{}, fmt.Errorf("selecting variant: %w", err)
+	}
+	if err := json.Unmarshal(overrides, &variant.Overrides); err != nil {
+		return cvVariant{}, fmt.Errorf("decoding overrides: %w", err)
+	}
+	return variant, nil
+}
+
+func (s *sqliteStore) ListVariants(ctx context.Context, cvID string) ([]cvVariant, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, name, overrides, created_at, updated_at FROM cv_variants WHERE cv_id = ? ORDER BY name", cvID)
+	if err != nil {
+		return nil, fmt.Errorf("listing variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := []cvVariant{}
+	for rows.Next() {
+		variant := cvVariant{BaseID: cvID}
+		var overrides []byte
+		if err := rows.Scan(&variant.ID, &variant.Name, &overrides, &variant.CreatedAt, &variant.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scanning variant: %w", err)
+		}
+		if err := json.Unmarshal(overrides, &variant.Overrides); err != nil {
+			return nil, fmt.Errorf("decoding overrides: %w", err)
+		}
+		variants = append(variants, variant)
+	}
+	return variants, rows.Err()
+}
+
+func (s *sqliteStore) UpdateVariant(ctx context.Context, variant *cvVariant) error {
+	overrides, err := json.Marshal(variant.Overrides)
+	if err != nil {
+		return fmt.Errorf("encoding overrides: %w", err)
+	}
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE cv_variants SET name = ?, overrides = ?, updated_at = ? WHERE id = ? AND cv_id = ?",
+		variant.Name, string(overrides), variant.UpdatedAt, variant.ID, variant.BaseID)
+	if err != nil {
+		return fmt.Errorf("updating variant: %w", err)
+	}
+	return requireRow(result)
+}
+
+func (s *sqliteStore) DeleteVariant(ctx context.Context, cvID, id string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM cv_variants WHERE id = ? AND cv_id = ?", id, cvID)
+	if err != nil {
+		return fmt.Errorf("deleting variant: %w", err)
+	}
+	return requireRow(result)
+}
+
+const shareColumns = "id, cv_id, token, template, password_hash, expires_at, revoked_at, created_at, do_not_track"
+
+func (s *sqliteStore) CreateShare(ctx context.Context, share *cvShare) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO cv_shares ("+shareColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		share.ID, share.CVID, share.Token, share.Template, share.PasswordHash, share.ExpiresAt, share.RevokedAt, share.CreatedAt,
+		share.DoNotTrack)
+	if err != nil {
+		return fmt.Errorf("inserting share: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) GetShareByToken(ctx context.Context, token string) (cvShare, error) {
+	share, err := scanShare(s.db.QueryRowContext(ctx,
+		"SELECT "+shareColumns+" FROM cv_shares WHERE token = ?", token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return cvShare{}, errNotFound
+	}
+	if err != nil {
+		return cvShare{}, fmt.Errorf("selecting share: %w", err)
+	}
+	return share, nil
+}
+
+func (s *sqliteStore) GetShare(ctx context.Context, cvID, id string) (cvShare, error) {
+	share, err := scanShare(s.db.QueryRowContext(ctx,
+		"SELECT "+shareColumns+" FROM cv_shares WHERE id = ? AND cv_id = ?", id, cvID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return cvShare{}, errNotFound
+	}
+	if err != nil {
+		return cvShare{}, fmt.Errorf("selecting share: %w", err)
+	}
+	return share, nil
+}
+
+func (s *sqliteStore) ListShares(ctx context.Context, cvID string) ([]cvShare, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+shareColumns+" FROM cv_shares WHERE cv_id = ? ORDER BY created_at DESC", cvID)
+	if err != nil {
+		return nil, fmt.Errorf("listing shares: %w", err)
+	}
+	defer rows.Close()
+
+	shares := []cvShare{}
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning share: %w", err)
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+// RevokeShare keeps the time of the first revocation if called again.
+func (s *sqliteStore) RevokeShare(ctx context.Context, cvID, id string, at time.Time) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE cv_shares SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ? AND cv_id = ?", at, id, cvID)
+	if err != nil {
+		return fmt.Errorf("revoking share: %w", err)
+	}
+	return requireRow(result)
+}
+
+// SetShareDoNotTrack also deletes the events already recorded for the share
+// when tracking is turned off.
+func (s *sqliteStore) SetShareDoNotTrack(ctx context.Context, cvID, id string, doNotTrack bool) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx,
+			"UPDATE cv_shares SET do_not_track = ? WHERE id = ? AND cv_id = ?", doNotTrack, id, cvID)
+		if err != nil {
+			return fmt.Errorf("updating share: %w", err)
+		}
+		if err := requireRow(result); err != nil || !doNotTrack {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM cv_share_events WHERE share_id = ?", id); err != nil {
+			return fmt.Errorf("deleting share events: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *sqliteStore) RecordShareEvent(ctx context.Context, event shareEvent) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO cv_share_events (share_id, kind, agent, referrer, created_at) VALUES (?, ?, ?, ?, ?)",
+		event.ShareID, event.Kind, event.Agent, event.Referrer, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("inserting share event: %w", err)
+	}
+	return nil
+}
+
+// ShareStats leaves bots out of every figure except Agents.
+func (s *sqliteStore) ShareStats(ctx context.Context, shareID string) (shareStats, error) {
+	stats := shareStats{Agents: map[string]int{}, Referrers: []referrerCount{}}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(kind = ? AND agent <> ?), 0), COALESCE(SUM(kind = ? AND agent <> ?), 0)
+		FROM cv_share_events WHERE share_id = ?`,
+		shareView, agentBot, shareDownload, agentBot, shareID).
+		Scan(&stats.Views, &stats.Downloads)
+	if err != nil {
+		return shareStats{}, fmt.Errorf("counting share events: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT agent, COUNT(*) FROM cv_share_events WHERE share_id = ? AND kind = ? GROUP BY agent",
+		shareID, shareView)
+	if err != nil {
+		return shareStats{}, fmt.Errorf("counting agents: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var agent string
+		var count int
+		if err := rows.Scan(&agent, &count); err != nil {
+			return shareStats{}, fmt.Errorf("scanning agent count: %w", err)
+		}
+		stats.Agents[agent] = count
+	}
+	if err := rows.Err(); err != nil {
+		return shareStats{}, err
+	}
+
+	rows, err = s.db.QueryContext(ctx,
+		`SELECT referrer, COUNT(*) FROM cv_share_events
+		WHERE share_id = ? AND kind = ? AND agent <> ? AND referrer <> ''
+		GROUP BY referrer ORDER BY COUNT(*) DESC, referrer`,
+		shareID, shareView, agentBot)
+	if err != nil {
+		return shareStats{}, fmt.Errorf("counting referrers: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var referrer referrerCount
+		if err := rows.Scan(&referrer.Host, &referrer.Views); err != nil {
+			return shareStats{}, fmt.Errorf("scanning referrer count: %w", err)
+		}
+		stats.Referrers = append(stats.Referrers, referrer)
+	}
+	if err := rows.Err(); err != nil {
+		return shareStats{}, err
+	}
+
+	// Times are read one row at a time rather than with MIN and MAX, whose
+	// results the driver cannot tell are timestamps.
+	times := []struct {
+		dest  **time.Time
+		kind  string
+		order string
+	}{
+		{&stats.FirstViewedAt, shareView, "ASC"},
+		{&stats.LastViewedAt, shareView, "DESC"},
+		{&stats.LastDownloadedAt, shareDownload, "DESC"},
+	}
+	for _, t := range times {
+		var at time.Time
+		err := s.db.QueryRowContext(ctx,
+			"SELECT created_at FROM cv_share_events WHERE share_id = ? AND kind = ? AND agent <> ? ORDER BY created_at "+t.order+" LIMIT 1",
+			shareID, t.kind, agentBot).Scan(&at)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return shareStats{}, fmt.Errorf("selecting share event time: %w", err)
+		}
+		*t.dest = &at
+	}
+	return stats, nil
+}
+
+func scanShare(row interface{ Scan(...any) error }) (cvShare, error) {
+	var share cvShare
+	var expiresAt, revokedAt sql.NullTime
+	err := row.Scan(&share.ID, &share.CVID, &share.Token, &share.Template, &share.PasswordHash,
+		&expiresAt, &revokedAt, &share.CreatedAt, &share.DoNotTrack)
+	if err != nil {
+		return cvShare{}, err
+	}
+	if expiresAt.Valid {
+		share.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		share.RevokedAt = &revokedAt.Time
+	}
+	return share, nil
+}
+
+func (s *sqliteStore) CreateUser(ctx context.Context, user *userAccount) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO users (id, email, name, password_hash, created_at) VALUES (?, ?, ?, ?, ?)",
+		user.ID, user.Email, user.Name, user.PasswordHash, user.CreatedAt)
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return errEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("inserting user: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) AdoptCVs(ctx context.Context, ownerID string) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "UPDATE cvs SET owner_id = ? WHERE owner_id IS NULL", ownerID)
+	if err != nil {
+		return 0, fmt.Errorf("adopting CVs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (s *sqliteStore) GetUserByEmail(ctx context.Context, email string) (userAccount, error) {
+	user := userAccount{}
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, email, name, password_hash, created_at FROM users WHERE email = ?", email).
+		Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return userAccount{}, errNotFound
+	}
+	if err != nil {
+		return userAccount{}, fmt.Errorf("selecting user: %w", err)
+	}
+	return user, nil
+}
+
+// CreateSession also clears out expired sessions.
+func (s *sqliteStore) CreateSession(ctx context.Context, session userSession) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at < ?", session.CreatedAt); err != nil {
+			return fmt.Errorf("deleting expired sessions: %w", err)
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+			session.TokenHash, session.UserID, session.CreatedAt, session.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("inserting session: %w", err)
+		}
+		return nil
+	})
+}
+
+// SessionUser returns the user signed in with the session and when the
+// session expires.
+func (s *sqliteStore) SessionUser(ctx context.Context, tokenHash string) (userAccount, time.Time, error) {
+	var user userAccount
+	var expiresAt time.Time
+	err := s.db.QueryRowContext(ctx,
+		`SELECT users.id, users.email, users.name, users.password_hash, users.created_at, sessions.expires_at
+		FROM sessions JOIN users ON users.id = sessions.user_id WHERE sessions.token_hash = ?`, tokenHash).
+		Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.CreatedAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return userAccount{}, time.Time{}, errNotFound
+	}
+	if err != nil {
+		return userAccount{}, time.Time{}, fmt.Errorf("selecting session: %w", err)
+	}
+	return user, expiresAt, nil
+}
+
+func (s *sqliteStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE token_hash = ?", tokenHash); err != nil {
+		return fmt.Errorf("deleting session: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) CreatePasswordReset(ctx context.Context, reset passwordReset) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO password_resets (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		reset.TokenHash, reset.UserID, reset.CreatedAt, reset.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("inserting password reset: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword uses up the reset token and sets the new password hash,
+// signing the user out everywhere and invalidating their other reset tokens.
+// Unknown and expired tokens return errNotFound.
+func (s *sqliteStore) ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var userID string
+		var expiresAt time.Time
+		err := tx.QueryRowContext(ctx,
+			"SELECT user_id, expires_at FROM password_resets WHERE token_hash = ?", tokenHash).
+			Scan(&userID, &expiresAt)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !now.Before(expiresAt)) {
+			return errNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("selecting password reset: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET password_hash = ? WHERE id = ?", passwordHash, userID); err != nil {
+			return fmt.Errorf("updating password: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM sessions WHERE user_id = ?", userID); err != nil {
+			return fmt.Errorf("deleting sessions: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM password_resets WHERE user_id = ?", userID); err != nil {
+			return fmt.Errorf("deleting password resets: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *sqliteStore) CVOwner(ctx context.Context, id string) (string, error) {
+	var owner sql.NullString
+	err := s.db.QueryRowContext(ctx, "SELECT owner_id FROM cvs WHERE id = ?", id).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("selecting CV owner: %w", err)
+	}
+	return owner.String, nil
+}
+
+// inTx runs fn in a transaction, committing only if it succeeds.
+func (s *sqliteStore) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// requireRow maps a statement that touched no rows onto errNotFound.
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNotFound
+	}
+	return nil
+}
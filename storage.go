@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	errNotFound   = errors.New("not found")
+	errEmailTaken = errors.New("email already registered")
+)
+
+// cvDocument is a saved CV together with its bookkeeping fields.
+type cvDocument struct {
+	ID        string    `json:"id"`
+	OwnerID   string    `json:"-"`
+	Revision  int       `json:"revision"`
+	Data      CVData    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// cvSummary is the listing view of a saved CV.
+type cvSummary struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// cvRevision is one saved version of a CV. Data is omitted from listings.
+type cvRevision struct {
+	Number    int       `json:"number"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      *CVData   `json:"data,omitempty"`
+}
+
+// cvStore persists CV documents and the accounts that own them. Every Create
+// and Update records a new revision and sets doc.Revision to its number.
+// Lookups of unknown IDs return errNotFound; CreateUser returns
+// errEmailTaken if the email is already registered. AdoptCVs gives CVs saved
+// before accounts existed to ownerID and returns how many there were.
+type cvStore interface {
+	Create(ctx context.Context, doc *cvDocument, author string) error
+	Get(ctx context.Context, id string) (cvDocument, error)
+	List(ctx context.Context, ownerID string) ([]cvSummary, error)
+	Update(ctx context.Context, doc *cvDocument, author string) error
+	Delete(ctx context.Context, id string) error
+	ListRevisions(ctx context.Context, id string) ([]cvRevision, error)
+	GetRevision(ctx context.Context, id string, number int) (cvRevision, error)
+	CreateVariant(ctx context.Context, variant *cvVariant) error
+	GetVariant(ctx context.Context, cvID, id string) (cvVariant, error)
+	ListVariants(ctx context.Context, cvID string) ([]cvVariant, error)
+	UpdateVariant(ctx context.Context, variant *cvVariant) error
+	DeleteVariant(ctx context.Context, cvID, id string) error
+	CreateShare(ctx context.Context, share *cvShare) error
+	GetShare(ctx context.Context, cvID, id string) (cvShare, error)
+	GetShareByToken(ctx context.Context, token string) (cvShare, error)
+	ListShares(ctx context.Context, cvID string) ([]cvShare, error)
+	RevokeShare(ctx context.Context, cvID, id string, at time.Time) error
+	SetShareDoNotTrack(ctx context.Context, cvID, id string, doNotTrack bool) error
+	RecordShareEvent(ctx context.Context, event shareEvent) error
+	ShareStats(ctx context.Context, shareID string) (shareStats, error)
+	CVOwner(ctx context.Context, id string) (string, error)
+	CreateUser(ctx context.Context, user *userAccount) error
+	AdoptCVs(ctx context.Context, ownerID string) (int64, error)
+	GetUserByEmail(ctx context.Context, email string) (userAccount, error)
+	CreateSession(ctx context.Context, session userSession) error
+	SessionUser(ctx context.Context, tokenHash string) (userAccount, time.Time, error)
+	DeleteSession(ctx context.Context, tokenHash string) error
+	CreatePasswordReset(ctx context.Context, reset passwordReset) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) error
+	Close() error
+}
+
+// store is the CV storage configured at startup.
+var store cvStore
+
+// newStore opens the storage backend selected by STORAGE.
+func newStore() (cvStore, error) {
+	switch name := getEnv("STORAGE", "sqlite"); name {
+	case "sqlite":
+		return openSQLiteStore(getEnv("DATABASE_PATH", "./data/cv-builder.db"))
+	default:
+		return nil, errors.New("unknown storage backend " + name)
+	}
+}
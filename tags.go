@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// TaggedText is a skill or duty with optional tags. In JSON it is written as
+// a plain string when untagged and as {"text": ..., "tags": [...]} otherwise;
+// both forms are accepted on input, so untagged documents are unchanged.
+// YAML follows the same rule. TOML output always uses the inline table form,
+// as the encoder cannot vary it per value, but plain strings are accepted.
+type TaggedText struct {
+	Text string   `json:"text" yaml:"text" toml:"text"`
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty" toml:"tags,omitempty"`
+}
+
+// taggedTextObject has TaggedText's fields without its JSON methods.
+type taggedTextObject TaggedText
+
+func (t TaggedText) MarshalJSON() ([]byte, error) {
+	if len(t.Tags) == 0 {
+		return json.Marshal(t.Text)
+	}
+	return json.Marshal(taggedTextObject(t))
+}
+
+func (t *TaggedText) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		*t = TaggedText{}
+		return json.Unmarshal(trimmed, &t.Text)
+	}
+	var object taggedTextObject
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&object); err != nil {
+		return err
+	}
+	*t = TaggedText(object)
+	return nil
+}
+
+func (t TaggedText) MarshalYAML() (any, error) {
+	if len(t.Tags) == 0 {
+		return t.Text, nil
+	}
+	return taggedTextObject(t), nil
+}
+
+func (t *TaggedText) UnmarshalYAML(node *yaml.Node) error {
+	*t = TaggedText{}
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&t.Text)
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if key := node.Content[i].Value; key != "text" && key != "tags" {
+				return fmt.Errorf("line %d: unknown field %q", node.Content[i].Line, key)
+			}
+		}
+	}
+	var object taggedTextObject
+	if err := node.Decode(&object); err != nil {
+		return err
+	}
+	*t = TaggedText(object)
+	return nil
+}
+
+// UnmarshalTOML reads a string or an inline table. Items written as
+// [[skills]] array tables are decoded as ordinary structs instead.
+func (t *TaggedText) UnmarshalTOML(value *unstable.Node) error {
+	*t = TaggedText{}
+	switch value.Kind {
+	case unstable.String:
+		t.Text = string(value.Data)
+		return nil
+	case unstable.InlineTable:
+	default:
+		return fmt.Errorf("expected a string or a table, got %s", value.Kind)
+	}
+
+	fields := value.Children()
+	for fields.Next() {
+		kv := fields.Node()
+		key := kv.Key()
+		key.Next()
+		name := string(key.Node().Data)
+		v := kv.Value()
+		switch {
+		case name == "text" && v.Kind == unstable.String:
+			t.Text = string(v.Data)
+		case name == "tags" && v.Kind == unstable.Array:
+			tags := v.Children()
+			for tags.Next() {
+				if tags.Node().Kind != unstable.String {
+					return fmt.Errorf("tags must be strings")
+				}
+				t.Tags = append(t.Tags, string(tags.Node().Data))
+			}
+		case name == "text" || name == "tags":
+			return fmt.Errorf("unexpected %s for %q", v.Kind, name)
+		default:
+			return fmt.Errorf("unknown field %q", name)
+		}
+	}
+	return nil
+}
+
+func textOf(t TaggedText) string {
+	return t.Text
+}
+
+// texts returns the text of each item.
+func texts(items []TaggedText) []string {
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = item.Text
+	}
+	return result
+}
+
+// untagged wraps plain strings as TaggedText items without tags.
+func untagged(items []string) []TaggedText {
+	result := make([]TaggedText, len(items))
+	for i, item := range items {
+		result[i] = TaggedText{Text: item}
+	}
+	return result
+}
+
+// compactTagged trims items and drops empty ones, normalising tags as
+// normalizeTags does.
+func compactTagged(items []TaggedText) []TaggedText {
+	result := make([]TaggedText, 0, len(items))
+	for _, item := range items {
+		if item.Text = strings.TrimSpace(item.Text); item.Text != "" {
+			item.Tags = normalizeTags(item.Tags)
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// normalizeTags lower-cases and trims tags, dropping blanks and duplicates.
+// It returns nil for an empty result so untagged items marshal as strings.
+func normalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && !containsString(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// tagFilter selects tagged content for rendering. Untagged items are
+// general-purpose and always kept. A tagged item is dropped if it carries an
+// excluded tag, or if Include is set and it carries none of those tags.
+type tagFilter struct {
+	Include []string
+	Exclude []string
+}
+
+func (f tagFilter) active() bool {
+	return len(f.Include) > 0 || len(f.Exclude) > 0
+}
+
+func (f tagFilter) keeps(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	included := len(f.Include) == 0
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if containsString(f.Exclude, tag) {
+			return false
+		}
+		if containsString(f.Include, tag) {
+			included = true
+		}
+	}
+	return included
+}
+
+func (f tagFilter) filter(items []TaggedText) []TaggedText {
+	result := make([]TaggedText, 0, len(items))
+	for _, item := range items {
+		if f.keeps(item.Tags) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// apply drops skills, roles and duties that the filter rejects.
+func (f tagFilter) apply(cvData CVData) CVData {
+	cvData.Skills = f.filter(cvData.Skills)
+	experience := make([]Experience, 0, len(cvData.Experience))
+	for _, exp := range cvData.Experience {
+		if f.keeps(exp.Tags) {
+			exp.Duties = f.filter(exp.Duties)
+			experience = append(experience, exp)
+		}
+	}
+	cvData.Experience = experience
+	return cvData
+}
+
+// parseTagList splits a comma-separated query value into normalised tags.
+func parseTagList(value string) []string {
+	return normalizeTags(strings.Split(value, ","))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTaggedTextJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  TaggedText
+		out   string
+	}{
+		{"plain string", `"Go"`, TaggedText{Text: "Go"}, `"Go"`},
+		{"object with tags", `{"text":"Go","tags":["backend"]}`, TaggedText{Text: "Go", Tags: []string{"backend"}}, `{"text":"Go","tags":["backend"]}`},
+		{"object without tags", `{"text":"Go"}`, TaggedText{Text: "Go"}, `"Go"`},
+	}
+	for _, tt := range tests {
+		var got TaggedText
+		if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+			t.Fatalf("%s: Unmarshal: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: decoded %+v, want %+v", tt.name, got, tt.want)
+		}
+		out, err := json.Marshal(got)
+		if err != nil {
+			t.Fatalf("%s: Marshal: %v", tt.name, err)
+		}
+		if string(out) != tt.out {
+			t.Errorf("%s: encoded %s, want %s", tt.name, out, tt.out)
+		}
+	}
+}
+
+func TestTaggedTextJSONRejectsUnknownFields(t *testing.T) {
+	for _, input := range []string{`{"text":"Go","tag":["backend"]}`, `42`, `["Go"]`} {
+		var got TaggedText
+		if err := json.Unmarshal([]byte(input), &got); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded with %+v", input, got)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{" Backend", "go", "", "backend", "GO "})
+	if want := []string{"backend", "go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeTags = %q, want %q", got, want)
+	}
+	if got := normalizeTags([]string{" ", ""}); got != nil {
+		t.Errorf("normalizeTags of blanks = %q, want nil", got)
+	}
+	if got := parseTagList("Go, leadership,,go"); !reflect.DeepEqual(got, []string{"go", "leadership"}) {
+		t.Errorf("parseTagList = %q", got)
+	}
+}
+
+func TestTagFilterKeeps(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter tagFilter
+		tags   []string
+		want   bool
+	}{
+		{"no filter", tagFilter{}, []string{"backend"}, true},
+		{"untagged with include", tagFilter{Include: []string{"backend"}}, nil, true},
+		{"untagged with exclude", tagFilter{Exclude: []string{"backend"}}, nil, true},
+		{"included", tagFilter{Include: []string{"backend"}}, []string{"go", "backend"}, true},
+		{"not included", tagFilter{Include: []string{"backend"}}, []string{"frontend"}, false},
+		{"excluded", tagFilter{Exclude: []string{"frontend"}}, []string{"frontend"}, false},
+		{"exclude wins", tagFilter{Include: []string{"go"}, Exclude: []string{"legacy"}}, []string{"go", "legacy"}, false},
+		{"case insensitive", tagFilter{Include: []string{"backend"}}, []string{"Backend"}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.keeps(tt.tags); got != tt.want {
+			t.Errorf("%s: keeps(%q) = %v, want %v", tt.name, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestTagFilterApply(t *testing.T) {
+	cvData := CVData{
+		Skills: []TaggedText{{Text: "Go", Tags: []string{"backend"}}, {Text: "React", Tags: []string{"frontend"}}, {Text: "Git"}},
+		Experience: []Experience{
+			{Title: "Engineer", Duties: []TaggedText{
+				{Text: "Built APIs", Tags: []string{"backend"}},
+				{Text: "Built UIs", Tags: []string{"frontend"}},
+				{Text: "Reviewed code"},
+			}},
+			{Title: "Designer", Tags: []string{"frontend"}},
+		},
+	}
+
+	got := tagFilter{Include: []string{"backend"}}.apply(cvData)
+	if want := []string{"Go", "Git"}; !reflect.DeepEqual(texts(got.Skills), want) {
+		t.Errorf("skills = %q, want %q", texts(got.Skills), want)
+	}
+	if len(got.Experience) != 1 || got.Experience[0].Title != "Engineer" {
+		t.Fatalf("experience = %+v, want only Engineer", got.Experience)
+	}
+	if want := []string{"Built APIs", "Reviewed code"}; !reflect.DeepEqual(texts(got.Experience[0].Duties), want) {
+		t.Errorf("duties = %q, want %q", texts(got.Experience[0].Duties), want)
+	}
+	if len(cvData.Experience[0].Duties) != 3 {
+		t.Errorf("apply modified its input")
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultTemplate = "classic"
+
+var errUnknownTemplate = errors.New("unknown template")
+
+// cvTemplateInfo describes a selectable CV layout. Clients choose one with
+// the template query parameter.
+type cvTemplateInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Columns     int    `json:"columns"`
+	ATSFriendly bool   `json:"ats_friendly"`
+
+	component func(CVData, renderOptions) templ.Component
+}
+
+// cvTemplates is the registry of available layouts, in display order.
+var cvTemplates = []cvTemplateInfo{
+	{
+		ID:          "classic",
+		Name:        "Classic",
+		Description: "Bordered sections with icon bullets for key skills.",
+		Columns:     1,
+		component:   cvTemplate,
+	},
+	{
+		ID:          "modern",
+		Name:        "Modern",
+		Description: "Two columns with contact details, skills and certifications in a coloured sidebar.",
+		Columns:     2,
+		component:   modernTemplate,
+	},
+	{
+		ID:          "minimal",
+		Name:        "Minimal",
+		Description: "Plain single column with conventional headings, suited to applicant tracking systems.",
+		Columns:     1,
+		ATSFriendly: true,
+		component:   minimalTemplate,
+	},
+}
+
+func lookupTemplate(id string) (cvTemplateInfo, bool) {
+	for _, t := range cvTemplates {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return cvTemplateInfo{}, false
+}
+
+func listTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"templates": cvTemplates,
+		"default":   defaultTemplate,
+	})
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// cvVariant is a tailored version of a saved CV. It stores only overrides,
+// which are applied to the current master content whenever the variant is
+// read, so edits to the master flow into every variant.
+type cvVariant struct {
+	ID        string           `json:"id"`
+	BaseID    string           `json:"base_id"`
+	Name      string           `json:"name"`
+	Overrides variantOverrides `json:"overrides"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// variantOverrides refers to skills, interests and duties by their text and
+// to roles by "Title at Company". Order lists move the named items to the
+// front in that order; anything not named keeps its master order after them.
+type variantOverrides struct {
+	Statement     *string                  `json:"statement,omitempty"`
+	HideSkills    []string                 `json:"hide_skills,omitempty"`
+	SkillOrder    []string                 `json:"skill_order,omitempty"`
+	HideRoles     []string                 `json:"hide_roles,omitempty"`
+	RoleOrder     []string                 `json:"role_order,omitempty"`
+	Roles         map[string]roleOverrides `json:"roles,omitempty"`
+	HideInterests []string                 `json:"hide_interests,omitempty"`
+}
+
+type roleOverrides struct {
+	HideDuties []string `json:"hide_duties,omitempty"`
+	DutyOrder  []string `json:"duty_order,omitempty"`
+}
+
+// resolveVariant applies overrides to the master CV. References that no
+// longer match anything in the master, typically because the item was
+// edited there, are ignored and reported as warnings.
+func resolveVariant(master CVData, o variantOverrides) (CVData, []string) {
+	warnings := []string{}
+	check := func(kind string, refs, available []string) {
+		for _, ref := range refs {
+			if !containsString(available, ref) {
+				warnings = append(warnings, fmt.Sprintf("%s %q is not in the master CV", kind, ref))
+			}
+		}
+	}
+
+	cv := master
+	if o.Statement != nil {
+		cv.Statement = *o.Statement
+	}
+
+	check("skill", append(o.HideSkills, o.SkillOrder...), texts(master.Skills))
+	cv.Skills = reorderItems(withoutItems(master.Skills, o.HideSkills, textOf), o.SkillOrder, textOf)
+
+	check("interest", o.HideInterests, master.Interests)
+	cv.Interests = withoutItems(master.Interests, o.HideInterests, identity)
+
+	roles := make([]string, len(master.Experience))
+	for i, exp := range master.Experience {
+		roles[i] = roleLabel(exp)
+	}
+	// Overridden roles are sorted so warnings come back in the same order
+	// on every request.
+	overridden := make([]string, 0, len(o.Roles))
+	for role := range o.Roles {
+		overridden = append(overridden, role)
+	}
+	sort.Strings(overridden)
+	roleRefs := append(append([]string{}, o.HideRoles...), o.RoleOrder...)
+	check("role", append(roleRefs, overridden...), roles)
+
+	experience := make([]Experience, 0, len(master.Experience))
+	for _, exp := range withoutItems(master.Experience, o.HideRoles, roleLabel) {
+		if ro, ok := o.Roles[roleLabel(exp)]; ok {
+			check(fmt.Sprintf("duty of %s", roleLabel(exp)), append(ro.HideDuties, ro.DutyOrder...), texts(exp.Duties))
+			exp.Duties = reorderItems(withoutItems(exp.Duties, ro.HideDuties, textOf), ro.DutyOrder, textOf)
+		}
+		experience = append(experience, exp)
+	}
+	cv.Experience = reorderItems(experience, o.RoleOrder, roleLabel)
+
+	return cv, warnings
+}
+
+// withoutItems returns a copy of items minus those whose key is in hide.
+func withoutItems[T any](items []T, hide []string, key func(T) string) []T {
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if !containsString(hide, key(item)) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// reorderItems moves the items named in order to the front, in that order,
+// followed by the rest in their original order.
+func reorderItems[T any](items []T, order []string, key func(T) string) []T {
+	result := make([]T, 0, len(items))
+	used := make([]bool, len(items))
+	for _, want := range order {
+		for i, item := range items {
+			if !used[i] && key(item) == want {
+				result = append(result, item)
+				used[i] = true
+				break
+			}
+		}
+	}
+	for i, item := range items {
+		if !used[i] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func listVariants(c *gin.Context) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return
+	}
+	variants, err := store.ListVariants(c.Request.Context(), doc.ID)
+	if err != nil {
+		respondStoreError(c, err, "Error listing variants", "Failed to list variants")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"variants": variants})
+}
+
+func createVariant(c *gin.Context) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return
+	}
+	variant, ok := bindVariant(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	variant.ID = uuid.New().String()
+	variant.BaseID = doc.ID
+	variant.CreatedAt, variant.UpdatedAt = now, now
+	if err := store.CreateVariant(c.Request.Context(), &variant); err != nil {
+		log.Printf("Error saving variant: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save variant"})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/cvs/%s/variants/%s", doc.ID, variant.ID))
+	c.JSON(http.StatusCreated, variant)
+}
+
+// getVariant returns the variant's overrides together with the effective CV
+// they produce from the current master.
+func getVariant(c *gin.Context) {
+	doc, variant, ok := loadVariant(c)
+	if !ok {
+		return
+	}
+	cvData, warnings := resolveVariant(doc.Data, variant.Overrides)
+	c.JSON(http.StatusOK, gin.H{
+		"variant":  variant,
+		"cv":       cvData,
+		"warnings": warnings,
+	})
+}
+
+func updateVariant(c *gin.Context) {
+	_, variant, ok := loadVariant(c)
+	if !ok {
+		return
+	}
+	update, ok := bindVariant(c)
+	if !ok {
+		return
+	}
+
+	variant.Name = update.Name
+	variant.Overrides = update.Overrides
+	variant.UpdatedAt = time.Now().UTC()
+	if err := store.UpdateVariant(c.Request.Context(), &variant); err != nil {
+		respondVariantError(c, err, "Error updating variant", "Failed to save variant")
+		return
+	}
+	c.JSON(http.StatusOK, variant)
+}
+
+func deleteVariant(c *gin.Context) {
+	if err := store.DeleteVariant(c.Request.Context(), c.Param("id"), c.Param("variant")); err != nil {
+		respondVariantError(c, err, "Error deleting variant", "Failed to delete variant")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func generateVariantPDF(c *gin.Context) {
+	doc, variant, ok := loadVariant(c)
+	if !ok {
+		return
+	}
+	cvData, _ := resolveVariant(doc.Data, variant.Overrides)
+	respondWithPDF(c, cvData)
+}
+
+// bindVariant decodes the name and overrides of a variant, rejecting unknown
+// fields so that misspelt override keys are not silently ignored.
+func bindVariant(c *gin.Context) (cvVariant, bool) {
+	var body struct {
+		Name      string           `json:"name"`
+		Overrides variantOverrides `json:"overrides"`
+	}
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		log.Printf("Error binding JSON: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return cvVariant{}, false
+	}
+	if body.Name = strings.TrimSpace(body.Name); body.Name == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Invalid variant",
+			"problems": []fieldError{{Field: "name", Message: "is required"}},
+		})
+		return cvVariant{}, false
+	}
+	return cvVariant{Name: body.Name, Overrides: body.Overrides}, true
+}
+
+func loadVariant(c *gin.Context) (cvDocument, cvVariant, bool) {
+	doc, ok := loadCV(c)
+	if !ok {
+		return cvDocument{}, cvVariant{}, false
+	}
+	variant, err := store.GetVariant(c.Request.Context(), doc.ID, c.Param("variant"))
+	if err != nil {
+		respondVariantError(c, err, "Error loading variant", "Failed to load variant")
+		return cvDocument{}, cvVariant{}, false
+	}
+	return doc, variant, true
+}
+
+func respondVariantError(c *gin.Context, err error, logPrefix, message string) {
+	if errors.Is(err, errNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variant not found"})
+		return
+	}
+	respondStoreError(c, err, logPrefix, message)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveVariant(t *testing.T) {
+	master := CVData{
+		Skills: untagged([]string{"Go", "SQL", "Rust"}),
+		Experience: []Experience{
+			{Title: "Engineer", Company: "Acme", Duties: untagged([]string{"Built", "Shipped", "Tested"})},
+			{Title: "Intern", Company: "Initech"},
+		},
+	}
+	o := variantOverrides{
+		HideSkills: []string{"SQL"},
+		SkillOrder: []string{"Rust"},
+		RoleOrder:  []string{"Intern at Initech"},
+		Roles: map[string]roleOverrides{
+			"Engineer at Acme": {HideDuties: []string{"Tested"}, DutyOrder: []string{"Shipped"}},
+		},
+	}
+
+	cv, warnings := resolveVariant(master, o)
+	if got := texts(cv.Skills); !reflect.DeepEqual(got, []string{"Rust", "Go"}) {
+		t.Errorf("skills = %q", got)
+	}
+	if len(cv.Experience) != 2 || cv.Experience[0].Title != "Intern" {
+		t.Fatalf("experience = %+v", cv.Experience)
+	}
+	if got := texts(cv.Experience[1].Duties); !reflect.DeepEqual(got, []string{"Shipped", "Built"}) {
+		t.Errorf("duties = %q", got)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings %q", warnings)
+	}
+	if len(master.Skills) != 3 || len(master.Experience[0].Duties) != 3 {
+		t.Errorf("master CV was modified: %+v", master)
+	}
+}
+
+func TestResolveVariantWarningsAreStable(t *testing.T) {
+	o := variantOverrides{
+		HideRoles: []string{"Gone at A"},
+		Roles: map[string]roleOverrides{
+			"Zeta at Z": {}, "Alpha at A": {}, "Mid at M": {}, "Beta at B": {},
+		},
+	}
+	want := []string{
+		`role "Gone at A" is not in the master CV`,
+		`role "Alpha at A" is not in the master CV`,
+		`role "Beta at B" is not in the master CV`,
+		`role "Mid at M" is not in the master CV`,
+		`role "Zeta at Z" is not in the master CV`,
+	}
+	for i := 0; i < 20; i++ {
+		if _, warnings := resolveVariant(CVData{}, o); !reflect.DeepEqual(warnings, want) {
+			t.Fatalf("warnings = %q, want %q", warnings, want)
+		}
+	}
+}